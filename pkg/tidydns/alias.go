@@ -0,0 +1,61 @@
+package tidydns
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+func (c *tidyDNSClient) ListZoneAliases(ctx context.Context, zoneID int) (_ []*Zone, err error) {
+	ctx, end := c.startOperation(ctx, "ListZoneAliases", attrZoneID.Int(zoneID))
+	defer func() { end(err) }()
+
+	zones, err := c.ListZonesDetailed(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Zone, 0)
+	for _, z := range zones {
+		if z.Type == ZoneTypeAlias && z.AliasID == zoneID {
+			result = append(result, z)
+		}
+	}
+	return result, nil
+}
+
+func (c *tidyDNSClient) CreateZoneAlias(ctx context.Context, name string, targetZoneID int) (_ int, err error) {
+	ctx, end := c.startOperation(ctx, "CreateZoneAlias", attrZoneName.String(name), attrZoneID.Int(targetZoneID))
+	defer func() { end(err) }()
+
+	if err := validateHostname("zone name", name); err != nil {
+		return 0, err
+	}
+	if targetZoneID <= 0 {
+		return 0, fmt.Errorf("%w: alias target zone ID %d", ErrInvalidArgument, targetZoneID)
+	}
+
+	data := url.Values{
+		"name":     {strings.TrimSuffix(name, ".")},
+		"type":     {strconv.Itoa(int(ZoneTypeAlias))},
+		"alias_id": {strconv.Itoa(targetZoneID)},
+	}
+	return c.createZone(ctx, data)
+}
+
+func (c *tidyDNSClient) DeleteZoneAlias(ctx context.Context, aliasID int) (err error) {
+	ctx, end := c.startOperation(ctx, "DeleteZoneAlias", attrZoneID.Int(aliasID))
+	defer func() { end(err) }()
+
+	// Guard against deleting the zone an alias mirrors by mistake.
+	zone, err := c.GetZone(ctx, aliasID)
+	if err != nil {
+		return err
+	}
+	if zone.Type != ZoneTypeAlias {
+		return fmt.Errorf("%w: zone %s is not an alias", ErrInvalidArgument, zone.Name)
+	}
+	return c.DeleteZone(ctx, aliasID)
+}
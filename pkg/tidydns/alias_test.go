@@ -0,0 +1,107 @@
+package tidydns
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListZoneAliases(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/=/zone", req.URL.Path)
+		_, _ = rw.Write([]byte(listZonesResponse))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	aliases, err := c.ListZoneAliases(context.Background(), 279)
+	assert.NoError(t, err)
+	if assert.Len(t, aliases, 1) {
+		assert.Equal(t, 1180, aliases[0].ID)
+		assert.Equal(t, "netic.eu", aliases[0].Name)
+	}
+
+	aliases, err = c.ListZoneAliases(context.Background(), 2926)
+	assert.NoError(t, err)
+	assert.Empty(t, aliases)
+}
+
+func TestListZonesAlias(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte(listZonesResponse))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	zones, err := c.ListZones(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, zones, 4)
+	assert.Equal(t, 0, zones[2].AliasID)
+	assert.Equal(t, 279, zones[3].AliasID)
+}
+
+func TestFindZoneIDAlias(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "netic.eu", req.URL.Query().Get("name"))
+		_, _ = rw.Write([]byte(`[{"id": 1180, "name": "netic.eu", "type": 2, "alias_id": 279, "alias_name": "netic.dk", "alias_type": 0}]`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	id, err := c.FindZoneID(context.Background(), "netic.eu")
+	assert.NoError(t, err)
+	assert.Equal(t, 279, id)
+}
+
+func TestCreateZoneAlias(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/=/zone/new":
+			assert.Equal(t, "POST", req.Method)
+			assert.NoError(t, req.ParseForm())
+			assert.Equal(t, "alias_id=279&name=netic.eu&type=2", req.PostForm.Encode())
+			_, _ = rw.Write([]byte(`{"status":"0"}`))
+		case "/=/zone":
+			// Without an ID in the response, the alias itself is looked up
+			// rather than the zone it mirrors.
+			_, _ = rw.Write([]byte(`[{"id": 1180, "name": "netic.eu", "type": 2, "alias_id": 279}]`))
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	id, err := c.CreateZoneAlias(context.Background(), "netic.eu.", 279)
+	assert.NoError(t, err)
+	assert.Equal(t, 1180, id)
+
+	_, err = c.CreateZoneAlias(context.Background(), "netic.eu", 0)
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+}
+
+func TestDeleteZoneAlias(t *testing.T) {
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case "GET":
+			if req.URL.Path == "/=/zone/1180" {
+				_, _ = rw.Write([]byte(`{"id": 1180, "name": "netic.eu", "type": 2, "alias_id": 279}`))
+				return
+			}
+			_, _ = rw.Write([]byte(getZoneResponse))
+		case "DELETE":
+			deleted = append(deleted, req.URL.Path)
+			_, _ = rw.Write([]byte(`{"status":"0"}`))
+		}
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	assert.ErrorIs(t, c.DeleteZoneAlias(context.Background(), 279), ErrInvalidArgument)
+	assert.NoError(t, c.DeleteZoneAlias(context.Background(), 1180))
+	assert.Equal(t, []string{"/=/zone/1180"}, deleted)
+}
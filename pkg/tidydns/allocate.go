@@ -0,0 +1,181 @@
+package tidydns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultAllocateAttempts is the number of addresses AllocateInterface
+	// tries if no limit is given.
+	defaultAllocateAttempts = 5
+	// defaultAllocateBackoff bounds the random delay of AllocateInterface
+	// after losing an address if no backoff is given.
+	defaultAllocateBackoff = 100 * time.Millisecond
+)
+
+// AllocateOptions control how AllocateInterface picks the address of a new
+// DHCP interface.
+type AllocateOptions struct {
+	// ZoneID is the zone of the interface. It defaults to the zone of the
+	// subnet.
+	ZoneID int
+	// LocationID is the location of the interface.
+	LocationID int
+	// Exclude are addresses that are never allocated, e.g. gateways not
+	// registered in TidyDNS.
+	Exclude []netip.Addr
+	// MaxAttempts is the number of addresses tried before giving up. It
+	// defaults to 5.
+	MaxAttempts int
+	// Backoff bounds the random delay before trying another address after
+	// a concurrent caller took the previous one. It defaults to 100ms.
+	Backoff time.Duration
+}
+
+func (o AllocateOptions) attempts() int {
+	if o.MaxAttempts > 0 {
+		return o.MaxAttempts
+	}
+	return defaultAllocateAttempts
+}
+
+func (o AllocateOptions) backoff() time.Duration {
+	if o.Backoff > 0 {
+		return o.Backoff
+	}
+	return defaultAllocateBackoff
+}
+
+func (c *tidyDNSClient) AllocateInterface(ctx context.Context, subnetID int, name string, opts AllocateOptions) (_ *InterfaceInfo, err error) {
+	ctx, end := c.startOperation(ctx, "AllocateInterface", attrSubnetID.Int(subnetID))
+	defer func() { end(err) }()
+
+	if name == "" {
+		return nil, fmt.Errorf("%w: interface name is empty", ErrInvalidArgument)
+	}
+	subnet, err := c.GetSubnet(ctx, subnetID)
+	if err != nil {
+		return nil, err
+	}
+	zoneID := opts.ZoneID
+	if zoneID == 0 {
+		zoneID = subnet.ZoneID
+	}
+
+	// Addresses lost to concurrent callers are skipped like excluded
+	// addresses, so every attempt tries a new address.
+	skip := make(map[netip.Addr]bool, len(opts.Exclude))
+	for _, addr := range opts.Exclude {
+		skip[addr.Unmap()] = true
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= opts.attempts(); attempt++ {
+		if attempt > 1 {
+			if err := sleep(ctx, rand.N(opts.backoff())+1); err != nil {
+				return nil, err
+			}
+		}
+
+		addr, err := c.freeAddr(ctx, subnet, skip)
+		if err != nil {
+			return nil, err
+		}
+		id, err := c.CreateDHCPInterface(ctx, CreateInfo{
+			SubnetID:      subnetID,
+			ZoneID:        zoneID,
+			InterfaceIP:   addr.String(),
+			InterfaceName: name,
+			LocationID:    opts.LocationID,
+		})
+		if err == nil {
+			return &InterfaceInfo{ID: id, InterfaceIP: addr.String(), Interfacename: name}, nil
+		}
+		if !addressTaken(err) {
+			return nil, err
+		}
+		// An earlier attempt of the same create, e.g. a POST retried by the
+		// retry policy, may have stored the interface before failing.
+		if info, err := c.findInterface(ctx, subnetID, addr, name); err != nil || info != nil {
+			return info, err
+		}
+		skip[addr] = true
+		lastErr = err
+	}
+	return nil, fmt.Errorf("no address allocated in subnet %d after %d attempts: %w", subnetID, opts.attempts(), lastErr)
+}
+
+// addressTaken reports whether creating an interface failed because its
+// address is already registered, e.g. "Key (destination)=(10.0.0.1) already
+// exists". Other conflicts, such as a duplicate name, are not solved by
+// trying another address.
+func addressTaken(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.alreadyExists() &&
+		strings.Contains(apiErr.Body, "Key (destination)=(")
+}
+
+// findInterface returns the interface of a subnet with the given address
+// and name, or nil if there is none.
+func (c *tidyDNSClient) findInterface(ctx context.Context, subnetID int, addr netip.Addr, name string) (*InterfaceInfo, error) {
+	interfaces, err := c.ListDHCPInterfaces(ctx, subnetID)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range interfaces {
+		ip, err := netip.ParseAddr(i.InterfaceIP)
+		if err == nil && ip.Unmap() == addr && strings.EqualFold(i.Interfacename, name) {
+			return i, nil
+		}
+	}
+	return nil, nil
+}
+
+// freeAddr returns a free address of a subnet not in skip. GetFreeIP always
+// returns the lowest free address, so if that is to be skipped the free
+// ranges of the subnet are searched instead.
+func (c *tidyDNSClient) freeAddr(ctx context.Context, subnet *Subnet, skip map[netip.Addr]bool) (netip.Addr, error) {
+	ip, err := c.GetFreeIP(ctx, subnet.ID)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("invalid free address %q: %w", ip, err)
+	}
+	if addr = addr.Unmap(); !skip[addr] {
+		return addr, nil
+	}
+
+	interfaces, err := c.ListDHCPInterfaces(ctx, subnet.ID)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	if addr, ok := firstFreeAddr(NewSubnetUsage(subnet, interfaces), skip); ok {
+		return addr, nil
+	}
+	return netip.Addr{}, fmt.Errorf("no free address in subnet %d", subnet.ID)
+}
+
+// firstFreeAddr returns the lowest free address of a subnet not in skip.
+func firstFreeAddr(usage *SubnetUsage, skip map[netip.Addr]bool) (netip.Addr, bool) {
+	for _, r := range usage.FreeRanges {
+		// Each range is left after at most len(skip)+1 addresses, so even
+		// IPv6 ranges are not enumerated.
+		for addr := r.First; ; addr = addr.Next() {
+			if !skip[addr] {
+				return addr, true
+			}
+			if addr == r.Last {
+				break
+			}
+		}
+	}
+	return netip.Addr{}, false
+}
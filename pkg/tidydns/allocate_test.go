@@ -0,0 +1,172 @@
+package tidydns
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// allocateServer serves a subnet whose free address is always reported as
+// 10.68.0.129, while creating interfaces on the addresses in taken fails as
+// if a concurrent caller got there first.
+func allocateServer(t *testing.T, taken map[string]bool, created *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.URL.Path == "/=/dhcp_subnet/1185":
+			_, _ = rw.Write([]byte(`{"id": 1185, "subnet": "10.68.0.128/26", "zone_id": 2861}`))
+		case req.URL.Path == "/=/dhcp_subnet_free_ip/1185":
+			_, _ = rw.Write([]byte(`{"status": 0, "data": {"ip_address": "10.68.0.129"}}`))
+		case req.URL.Path == "/=/dhcp_interface/":
+			_, _ = rw.Write([]byte(`[]`))
+		case req.Method == "POST" && req.URL.Path == "/=/dhcp_interface//new":
+			assert.NoError(t, req.ParseForm())
+			assert.Equal(t, "2861", req.PostForm.Get("zone_id"))
+			assert.Equal(t, "node1", req.PostForm.Get("name"))
+			destination := req.PostForm.Get("destination")
+			*created = append(*created, destination)
+			if taken[destination] {
+				rw.WriteHeader(http.StatusInternalServerError)
+				_, _ = fmt.Fprintf(rw, `ERROR: duplicate key value violates unique constraint "tidy_record_destination_key" DETAIL: Key (destination)=(%s) already exists.`, destination)
+				return
+			}
+			_, _ = rw.Write([]byte(createResponseV2))
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		}
+	}))
+}
+
+func TestAllocateInterface(t *testing.T) {
+	var created []string
+	server := allocateServer(t, map[string]bool{"10.68.0.129": true}, &created)
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	info, err := c.AllocateInterface(context.Background(), 1185, "node1", AllocateOptions{Backoff: time.Millisecond})
+	assert.NoError(t, err)
+	assert.Equal(t, &InterfaceInfo{ID: 30641, InterfaceIP: "10.68.0.130", Interfacename: "node1"}, info)
+	assert.Equal(t, []string{"10.68.0.129", "10.68.0.130"}, created)
+}
+
+func TestAllocateInterfaceExclude(t *testing.T) {
+	var created []string
+	server := allocateServer(t, nil, &created)
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	info, err := c.AllocateInterface(context.Background(), 1185, "node1", AllocateOptions{
+		Exclude: []netip.Addr{netip.MustParseAddr("10.68.0.129"), netip.MustParseAddr("::ffff:10.68.0.130")},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "10.68.0.131", info.InterfaceIP)
+	assert.Equal(t, []string{"10.68.0.131"}, created)
+}
+
+func TestAllocateInterfaceGivesUp(t *testing.T) {
+	var created []string
+	taken := map[string]bool{"10.68.0.129": true, "10.68.0.130": true, "10.68.0.131": true}
+	server := allocateServer(t, taken, &created)
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	_, err := c.AllocateInterface(context.Background(), 1185, "node1", AllocateOptions{MaxAttempts: 3, Backoff: time.Millisecond})
+	assert.ErrorIs(t, err, ErrAlreadyExists)
+	assert.ErrorContains(t, err, "after 3 attempts")
+	assert.Equal(t, []string{"10.68.0.129", "10.68.0.130", "10.68.0.131"}, created)
+
+	_, err = c.AllocateInterface(context.Background(), 1185, "", AllocateOptions{})
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+}
+
+func TestAllocateInterfaceCreatedBefore(t *testing.T) {
+	var created []string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.URL.Path == "/=/dhcp_subnet/1185":
+			_, _ = rw.Write([]byte(`{"id": 1185, "subnet": "10.68.0.128/26", "zone_id": 2861}`))
+		case req.URL.Path == "/=/dhcp_subnet_free_ip/1185":
+			_, _ = rw.Write([]byte(`{"status": 0, "data": {"ip_address": "10.68.0.129"}}`))
+		case req.URL.Path == "/=/dhcp_interface/":
+			assert.Equal(t, "1185", req.URL.Query().Get("subnet_id"))
+			_, _ = rw.Write([]byte(`[{"id": 30641, "name": "node1", "destination": "10.68.0.129"}]`))
+		case req.Method == "POST" && req.URL.Path == "/=/dhcp_interface//new":
+			assert.NoError(t, req.ParseForm())
+			created = append(created, req.PostForm.Get("destination"))
+			// The first attempt timed out after the interface was stored,
+			// so the retried POST finds the address taken.
+			if len(created) == 1 {
+				rw.WriteHeader(http.StatusGatewayTimeout)
+				return
+			}
+			rw.WriteHeader(http.StatusInternalServerError)
+			_, _ = rw.Write([]byte(`ERROR: duplicate key value violates unique constraint "tidy_record_destination_key" DETAIL: Key (destination)=(10.68.0.129) already exists.`))
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewWithOptions(server.URL, "username", "password", WithRetryPolicy(RetryPolicy{
+		MaxAttempts:        2,
+		MinBackoff:         time.Millisecond,
+		RetryNonIdempotent: true,
+	}))
+	assert.NoError(t, err)
+	info, err := c.AllocateInterface(context.Background(), 1185, "node1", AllocateOptions{Backoff: time.Millisecond})
+	assert.NoError(t, err)
+	assert.Equal(t, &InterfaceInfo{ID: 30641, InterfaceIP: "10.68.0.129", Interfacename: "node1"}, info)
+	assert.Equal(t, []string{"10.68.0.129", "10.68.0.129"}, created)
+}
+
+func TestAllocateInterfaceNameTaken(t *testing.T) {
+	var created []string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.URL.Path == "/=/dhcp_subnet/1185":
+			_, _ = rw.Write([]byte(`{"id": 1185, "subnet": "10.68.0.128/26", "zone_id": 2861}`))
+		case req.URL.Path == "/=/dhcp_subnet_free_ip/1185":
+			_, _ = rw.Write([]byte(`{"status": 0, "data": {"ip_address": "10.68.0.129"}}`))
+		case req.Method == "POST" && req.URL.Path == "/=/dhcp_interface//new":
+			assert.NoError(t, req.ParseForm())
+			created = append(created, req.PostForm.Get("destination"))
+			rw.WriteHeader(http.StatusInternalServerError)
+			_, _ = rw.Write([]byte(`ERROR: duplicate key value violates unique constraint "tidy_record_name_key" DETAIL: Key (name)=(node1) already exists.`))
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	_, err := c.AllocateInterface(context.Background(), 1185, "node1", AllocateOptions{Backoff: time.Millisecond})
+	assert.ErrorIs(t, err, ErrAlreadyExists)
+	assert.NotContains(t, err.Error(), "attempts")
+	assert.Equal(t, []string{"10.68.0.129"}, created)
+}
+
+func TestFirstFreeAddr(t *testing.T) {
+	usage := &SubnetUsage{FreeRanges: []AddressRange{
+		addrRange("10.68.0.130", "10.68.0.131"),
+		addrRange("2a01:4d0:1::1", "2a01:4d0:1::ffff"),
+	}}
+	skip := map[netip.Addr]bool{netip.MustParseAddr("10.68.0.130"): true}
+
+	addr, ok := firstFreeAddr(usage, skip)
+	assert.True(t, ok)
+	assert.Equal(t, "10.68.0.131", addr.String())
+
+	skip[netip.MustParseAddr("10.68.0.131")] = true
+	skip[netip.MustParseAddr("2a01:4d0:1::1")] = true
+	addr, ok = firstFreeAddr(usage, skip)
+	assert.True(t, ok)
+	assert.Equal(t, "2a01:4d0:1::2", addr.String())
+
+	_, ok = firstFreeAddr(&SubnetUsage{}, skip)
+	assert.False(t, ok)
+}
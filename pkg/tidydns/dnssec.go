@@ -0,0 +1,31 @@
+package tidydns
+
+import (
+	"context"
+)
+
+func (c *tidyDNSClient) SetDNSSEC(ctx context.Context, zoneID int, enabled bool) (err error) {
+	ctx, end := c.startOperation(ctx, "SetDNSSEC", attrZoneID.Int(zoneID))
+	defer func() { end(err) }()
+
+	return c.UpdateZone(ctx, zoneID, ZoneSettings{DNSSECEnabled: &enabled})
+}
+
+func (c *tidyDNSClient) GenerateDNSSECKeys(ctx context.Context, zoneID int) (err error) {
+	ctx, end := c.startOperation(ctx, "GenerateDNSSECKeys", attrZoneID.Int(zoneID))
+	defer func() { end(err) }()
+
+	generate := true
+	return c.UpdateZone(ctx, zoneID, ZoneSettings{DNSSECGenerateKeys: &generate})
+}
+
+func (c *tidyDNSClient) GetDNSSECStatus(ctx context.Context, zoneID int) (_ *DNSSEC, err error) {
+	ctx, end := c.startOperation(ctx, "GetDNSSECStatus", attrZoneID.Int(zoneID))
+	defer func() { end(err) }()
+
+	zone, err := c.GetZone(ctx, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	return &zone.DNSSEC, nil
+}
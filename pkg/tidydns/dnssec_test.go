@@ -0,0 +1,41 @@
+package tidydns
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetDNSSEC(t *testing.T) {
+	var forms []string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "POST", req.Method)
+		assert.Equal(t, "/=/zone/279", req.URL.Path)
+		assert.NoError(t, req.ParseForm())
+		forms = append(forms, req.PostForm.Encode())
+		_, _ = rw.Write([]byte(`{"status":"0"}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	assert.NoError(t, c.SetDNSSEC(context.Background(), 279, true))
+	assert.NoError(t, c.SetDNSSEC(context.Background(), 279, false))
+	assert.NoError(t, c.GenerateDNSSECKeys(context.Background(), 279))
+	assert.Equal(t, []string{"dnssec_enable=1", "dnssec_enable=0", "dnssec_genkeys=1"}, forms)
+}
+
+func TestGetDNSSECStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte(getZoneResponse))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	status, err := c.GetDNSSECStatus(context.Background(), 279)
+	assert.NoError(t, err)
+	assert.True(t, status.Enabled)
+	assert.Equal(t, 1, status.ParentState)
+}
@@ -0,0 +1,240 @@
+package tidydns
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// enum holds the names of the values of an integer enumeration used by
+// TidyDNS. Names are matched ignoring case and punctuation, so "A+PTR",
+// "APTR" and "a_ptr" all name the same record type.
+type enum[T ~int] struct {
+	kind  string
+	names map[T]string
+}
+
+var recordTypes = enum[RecordType]{
+	kind: "record type",
+	names: map[RecordType]string{
+		RecordTypeA:     "A",
+		RecordTypeAPTR:  "A+PTR",
+		RecordTypeCNAME: "CNAME",
+		RecordTypeMX:    "MX",
+		RecordTypeNS:    "NS",
+		RecordTypeTXT:   "TXT",
+		RecordTypeSRV:   "SRV",
+		RecordTypeDS:    "DS",
+		RecordTypeSSHFP: "SSHFP",
+		RecordTypeTLSA:  "TLSA",
+		RecordTypeCAA:   "CAA",
+	},
+}
+
+var recordStatuses = enum[RecordStatus]{
+	kind: "record status",
+	names: map[RecordStatus]string{
+		RecordStatusActive:   "Active",
+		RecordStatusInactive: "Inactive",
+		RecordStatusDeleted:  "Deleted",
+	},
+}
+
+// authGroups uses the names TidyDNS reports for the auth group of a user.
+var authGroups = enum[AuthGroup]{
+	kind: "auth group",
+	names: map[AuthGroup]string{
+		AuthGroupUser:       "User",
+		AuthGroupSuperAdmin: "SuperAdmin",
+	},
+}
+
+var zoneTypes = enum[ZoneType]{
+	kind: "zone type",
+	names: map[ZoneType]string{
+		ZoneTypeRegular: "regular",
+		ZoneTypeAlias:   "alias",
+	},
+}
+
+func (e enum[T]) known(v T) bool {
+	_, ok := e.names[v]
+	return ok
+}
+
+func (e enum[T]) string(v T, typeName string) string {
+	if name, ok := e.names[v]; ok {
+		return name
+	}
+	return typeName + "(" + strconv.Itoa(int(v)) + ")"
+}
+
+func (e enum[T]) parse(name string) (T, error) {
+	key := normalizeName(name)
+	for v, n := range e.names {
+		if normalizeName(n) == key {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown %s: %q", e.kind, name)
+}
+
+// marshalText returns the name of v, or its number if it has no name, so
+// values read from TidyDNS survive a round trip even if they are not known
+// by this package.
+func (e enum[T]) marshalText(v T) []byte {
+	if name, ok := e.names[v]; ok {
+		return []byte(name)
+	}
+	return []byte(strconv.Itoa(int(v)))
+}
+
+func (e enum[T]) unmarshalText(text []byte) (T, error) {
+	if n, err := strconv.Atoi(string(text)); err == nil {
+		return T(n), nil
+	}
+	return e.parse(string(text))
+}
+
+// unmarshalJSON accepts both names and the numbers used by the TidyDNS API.
+func (e enum[T]) unmarshalJSON(data []byte) (T, error) {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return 0, err
+		}
+		return e.unmarshalText([]byte(s))
+	}
+	var n int
+	if err := json.Unmarshal(data, &n); err != nil {
+		return 0, fmt.Errorf("invalid %s: %s", e.kind, data)
+	}
+	return T(n), nil
+}
+
+func normalizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return -1
+	}, name)
+}
+
+// ParseRecordType returns the record type with the given name, e.g. "CNAME"
+// or "A+PTR". Case and punctuation are ignored.
+func ParseRecordType(name string) (RecordType, error) {
+	return recordTypes.parse(name)
+}
+
+func (t RecordType) String() string {
+	return recordTypes.string(t, "RecordType")
+}
+
+func (t RecordType) MarshalText() ([]byte, error) {
+	return recordTypes.marshalText(t), nil
+}
+
+func (t *RecordType) UnmarshalText(text []byte) (err error) {
+	*t, err = recordTypes.unmarshalText(text)
+	return err
+}
+
+func (t *RecordType) UnmarshalJSON(data []byte) (err error) {
+	*t, err = recordTypes.unmarshalJSON(data)
+	return err
+}
+
+func (t RecordType) known() bool {
+	return recordTypes.known(t)
+}
+
+// resolveRecordType determines the type of a record read from TidyDNS from
+// its numeric type and, if present, the type name. Types not known by this
+// package, and types where the name contradicts the number, are reported as
+// RecordTypeUnknown rather than guessed.
+func resolveRecordType(id RecordType, name string) RecordType {
+	if !id.known() {
+		return RecordTypeUnknown
+	}
+	if byName, err := ParseRecordType(name); err == nil && byName != id {
+		return RecordTypeUnknown
+	}
+	return id
+}
+
+// ParseRecordStatus returns the record status with the given name, e.g.
+// "Active". Case is ignored.
+func ParseRecordStatus(name string) (RecordStatus, error) {
+	return recordStatuses.parse(name)
+}
+
+func (s RecordStatus) String() string {
+	return recordStatuses.string(s, "RecordStatus")
+}
+
+func (s RecordStatus) MarshalText() ([]byte, error) {
+	return recordStatuses.marshalText(s), nil
+}
+
+func (s *RecordStatus) UnmarshalText(text []byte) (err error) {
+	*s, err = recordStatuses.unmarshalText(text)
+	return err
+}
+
+func (s *RecordStatus) UnmarshalJSON(data []byte) (err error) {
+	*s, err = recordStatuses.unmarshalJSON(data)
+	return err
+}
+
+// ParseAuthGroup returns the auth group with the given name as reported by
+// TidyDNS, e.g. "SuperAdmin". Case and punctuation are ignored.
+func ParseAuthGroup(name string) (AuthGroup, error) {
+	return authGroups.parse(name)
+}
+
+func (g AuthGroup) String() string {
+	return authGroups.string(g, "AuthGroup")
+}
+
+func (g AuthGroup) MarshalText() ([]byte, error) {
+	return authGroups.marshalText(g), nil
+}
+
+func (g *AuthGroup) UnmarshalText(text []byte) (err error) {
+	*g, err = authGroups.unmarshalText(text)
+	return err
+}
+
+func (g *AuthGroup) UnmarshalJSON(data []byte) (err error) {
+	*g, err = authGroups.unmarshalJSON(data)
+	return err
+}
+
+// ParseZoneType returns the zone type with the given name as reported by
+// TidyDNS, e.g. "alias". Case is ignored.
+func ParseZoneType(name string) (ZoneType, error) {
+	return zoneTypes.parse(name)
+}
+
+func (t ZoneType) String() string {
+	return zoneTypes.string(t, "ZoneType")
+}
+
+func (t ZoneType) MarshalText() ([]byte, error) {
+	return zoneTypes.marshalText(t), nil
+}
+
+func (t *ZoneType) UnmarshalText(text []byte) (err error) {
+	*t, err = zoneTypes.unmarshalText(text)
+	return err
+}
+
+func (t *ZoneType) UnmarshalJSON(data []byte) (err error) {
+	*t, err = zoneTypes.unmarshalJSON(data)
+	return err
+}
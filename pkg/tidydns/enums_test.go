@@ -0,0 +1,128 @@
+package tidydns
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveRecordType(t *testing.T) {
+	tests := []struct {
+		id   RecordType
+		name string
+		want RecordType
+	}{
+		{RecordTypeA, "A", RecordTypeA},
+		{RecordTypeA, "", RecordTypeA},
+		{RecordTypeAPTR, "A+PTR", RecordTypeAPTR},
+		{RecordTypeAPTR, "a_ptr", RecordTypeAPTR},
+		{RecordTypeCAA, "CAA", RecordTypeCAA},
+		{RecordType(12), "AAAA", RecordTypeUnknown},
+		{RecordTypeA, "CNAME", RecordTypeUnknown},
+		{RecordTypeA, "URI", RecordTypeA},
+		{RecordType(42), "URI", RecordTypeUnknown},
+		{RecordType(42), "", RecordTypeUnknown},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, resolveRecordType(tt.id, tt.name), "%d %q", tt.id, tt.name)
+	}
+}
+
+func TestListRecordsUnknownType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte(`[
+  {"id": 1, "type": 12, "type_name": "AAAA", "name": "www", "destination": "2001:db8::1", "status": "0"},
+  {"id": 2, "type": 42, "type_name": "URI", "name": "_http._tcp", "destination": "10 1 \"http://www\"", "status": "0"}
+]`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	records, err := c.ListRecords(context.Background(), 2861)
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, RecordTypeUnknown, records[0].Type)
+	assert.Equal(t, "AAAA", records[0].TypeName)
+	assert.Equal(t, RecordTypeUnknown, records[1].Type)
+	assert.Equal(t, "URI", records[1].TypeName)
+}
+
+func TestCreateRecordUnknownType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		t.Error("unexpected request")
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	_, err := c.CreateRecord(context.Background(), 2861, RecordInfo{Type: RecordTypeUnknown, Name: "www"})
+	assert.Error(t, err)
+}
+
+func TestRecordTypeString(t *testing.T) {
+	assert.Equal(t, "CNAME", RecordTypeCNAME.String())
+	assert.Equal(t, "A+PTR", RecordTypeAPTR.String())
+	assert.Equal(t, "RecordType(42)", RecordType(42).String())
+	assert.Equal(t, "Inactive", RecordStatusInactive.String())
+	assert.Equal(t, "SuperAdmin", AuthGroupSuperAdmin.String())
+}
+
+func TestParseEnums(t *testing.T) {
+	rt, err := ParseRecordType("CNAME")
+	assert.NoError(t, err)
+	assert.Equal(t, RecordTypeCNAME, rt)
+	rt, err = ParseRecordType("a+ptr")
+	assert.NoError(t, err)
+	assert.Equal(t, RecordTypeAPTR, rt)
+	_, err = ParseRecordType("URI")
+	assert.EqualError(t, err, `unknown record type: "URI"`)
+
+	rs, err := ParseRecordStatus("deleted")
+	assert.NoError(t, err)
+	assert.Equal(t, RecordStatusDeleted, rs)
+
+	ag, err := ParseAuthGroup("super_admin")
+	assert.NoError(t, err)
+	assert.Equal(t, AuthGroupSuperAdmin, ag)
+	_, err = ParseAuthGroup("Admin")
+	assert.Error(t, err)
+}
+
+func TestEnumJSON(t *testing.T) {
+	type config struct {
+		Type   RecordType   `json:"type"`
+		Status RecordStatus `json:"status"`
+		Group  AuthGroup    `json:"group"`
+	}
+
+	data, err := json.Marshal(config{RecordTypeMX, RecordStatusActive, AuthGroupUser})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type":"MX","status":"Active","group":"User"}`, string(data))
+
+	data, err = json.Marshal(config{Type: RecordTypeUnknown, Status: RecordStatus(-1)})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type":"-1","status":"-1","group":"0"}`, string(data))
+
+	var c config
+	assert.NoError(t, json.Unmarshal([]byte(`{"type":"srv","status":"Inactive","group":"SuperAdmin"}`), &c))
+	assert.Equal(t, config{RecordTypeSRV, RecordStatusInactive, AuthGroupSuperAdmin}, c)
+
+	// The TidyDNS API uses numbers, sometimes as strings.
+	assert.NoError(t, json.Unmarshal([]byte(`{"type":6,"status":"1","group":1}`), &c))
+	assert.Equal(t, config{RecordTypeSRV, RecordStatusInactive, AuthGroupSuperAdmin}, c)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"type":"URI"}`), &c))
+	assert.Error(t, json.Unmarshal([]byte(`{"type":true}`), &c))
+}
+
+func TestEnumText(t *testing.T) {
+	var rt RecordType
+	assert.NoError(t, rt.UnmarshalText([]byte("TLSA")))
+	assert.Equal(t, RecordTypeTLSA, rt)
+	text, err := rt.MarshalText()
+	assert.NoError(t, err)
+	assert.Equal(t, "TLSA", string(text))
+}
@@ -0,0 +1,122 @@
+package tidydns
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Sentinel errors that API errors can be matched against with errors.Is.
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrUnauthorized  = errors.New("unauthorized")
+	ErrForbidden     = errors.New("forbidden")
+	ErrConflict      = errors.New("conflict")
+	ErrAlreadyExists = errors.New("already exists")
+)
+
+// ErrInvalidArgument is returned, wrapped, when arguments are rejected by
+// the client before anything is sent to TidyDNS.
+var ErrInvalidArgument = errors.New("invalid argument")
+
+// maxErrorBody limits how much of an error response is kept on an APIError.
+const maxErrorBody = 4096
+
+// APIError is returned when TidyDNS answers with a status other than 200 OK.
+type APIError struct {
+	StatusCode int
+	Status     string
+	Method     string
+	Path       string
+	// Body is the response body, truncated to a few kilobytes.
+	Body string
+	// Message is the error message reported by TidyDNS, if any.
+	Message string
+	// RetryAfter is the delay requested by a Retry-After header, if any.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf(errorTidyDNS, e.Status)
+	msg = fmt.Sprintf("%s (%s %s)", msg, e.Method, e.Path)
+	if e.Message != "" {
+		msg = fmt.Sprintf("%s: %s", msg, e.Message)
+	}
+	return msg
+}
+
+// Is reports whether the error matches one of the sentinel errors.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrAlreadyExists:
+		return e.alreadyExists()
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict || e.alreadyExists()
+	}
+	return false
+}
+
+// alreadyExists detects unique constraint violations, which TidyDNS reports
+// as e.g. "Key (destination)=(10.0.0.1) already exists".
+func (e *APIError) alreadyExists() bool {
+	return strings.Contains(e.Body, ") already exists")
+}
+
+func newAPIError(res *http.Response) *APIError {
+	apiErr := &APIError{
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+		RetryAfter: parseRetryAfter(res.Header.Get("Retry-After")),
+	}
+	if res.Request != nil {
+		apiErr.Method = res.Request.Method
+		apiErr.Path = res.Request.URL.Path
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBody+1))
+	if len(body) > maxErrorBody {
+		body = body[:maxErrorBody]
+	}
+	apiErr.Body = string(body)
+	apiErr.Message = errorMessage(body)
+
+	return apiErr
+}
+
+// errorMessage extracts the error message from a TidyDNS error response.
+// Responses are usually JSON with an error or message field, but proxies and
+// unhandled server errors may return plain text.
+func errorMessage(body []byte) string {
+	var data struct {
+		Error   interface{} `json:"error"`
+		Message interface{} `json:"message"`
+		Msg     interface{} `json:"msg"`
+	}
+	if err := json.Unmarshal(body, &data); err == nil {
+		for _, v := range []interface{}{data.Error, data.Message, data.Msg} {
+			if s, ok := v.(string); ok && s != "" {
+				return s
+			}
+		}
+		return ""
+	}
+
+	text := strings.TrimSpace(string(body))
+	if text == "" || strings.HasPrefix(text, "<") {
+		return ""
+	}
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = text[:i]
+	}
+	return text
+}
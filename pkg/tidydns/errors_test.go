@@ -0,0 +1,108 @@
+package tidydns
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIErrorNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusNotFound)
+		_, _ = rw.Write([]byte(`{"status":"1","error":"No such record"}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	_, err := c.ReadRecord(context.Background(), 2861, 64694)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NotErrorIs(t, err, ErrUnauthorized)
+
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, "GET", apiErr.Method)
+	assert.Equal(t, "/=/record/2861/64694", apiErr.Path)
+	assert.Equal(t, "No such record", apiErr.Message)
+	assert.Contains(t, apiErr.Error(), "404 Not Found")
+}
+
+func TestAPIErrorUnauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	err := c.DeleteInternalUser(context.Background(), UserID(144))
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestCreateDHCPInterfaceAlreadyExists(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusInternalServerError)
+		_, _ = rw.Write([]byte(`ERROR: duplicate key value violates unique constraint "tidy_record_destination_key" DETAIL: Key (destination)=(10.68.0.134) already exists.`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	id, err := c.CreateDHCPInterface(context.Background(), CreateInfo{
+		SubnetID:      1185,
+		ZoneID:        2861,
+		InterfaceIP:   "10.68.0.134",
+		InterfaceName: "unittest",
+	})
+	assert.Equal(t, 0, id)
+	assert.ErrorIs(t, err, ErrAlreadyExists)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.NotErrorIs(t, err, ErrNotFound)
+}
+
+func TestCreateInternalUserAlreadyExists(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusInternalServerError)
+		_, _ = rw.Write([]byte(`{"status":"1","error":"Key (username)=(test_user) already exists."}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	_, err := c.CreateInternalUser(context.Background(), "test_user", "test_password", "", false, AuthGroupUser, nil)
+	assert.ErrorIs(t, err, ErrAlreadyExists)
+
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "Key (username)=(test_user) already exists.", apiErr.Message)
+}
+
+func TestAPIErrorTruncatesBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusBadGateway)
+		_, _ = rw.Write([]byte("<html>" + strings.Repeat("x", 2*maxErrorBody) + "</html>"))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	_, err := c.ListZones(context.Background())
+
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+	assert.Len(t, apiErr.Body, maxErrorBody)
+	assert.Empty(t, apiErr.Message)
+}
+
+func TestFindZoneIDNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	_, err := c.FindZoneID(context.Background(), "unknown.example.com")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,140 @@
+package tidydns
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+const headerRequestID = "X-Request-ID"
+
+const redacted = "[REDACTED]"
+
+// sensitiveFields are form fields and JSON keys never written to the log.
+var sensitiveFields = []string{"epassword", "epassword_verify"}
+
+var sensitiveJSON = regexp.MustCompile(`("epassword(?:_verify)?"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+// WithLogger logs every HTTP request sent by the client. A summary with
+// method, path, status, latency and request ID is logged at info level for
+// successful requests and at warn level for failures. Request and response
+// bodies are logged at debug level with passwords and credentials redacted.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (c *tidyDNSClient) logRequest(req *http.Request, attempt int) {
+	if c.logger == nil || !c.logger.Enabled(req.Context(), slog.LevelDebug) {
+		return
+	}
+
+	var body string
+	if req.GetBody != nil {
+		if r, err := req.GetBody(); err == nil {
+			b, _ := io.ReadAll(r)
+			_ = r.Close()
+			body = redactForm(string(b))
+		}
+	}
+
+	c.logger.LogAttrs(req.Context(), slog.LevelDebug, "tidydns request",
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.Path),
+		slog.String("request_id", req.Header.Get(headerRequestID)),
+		slog.Int("attempt", attempt),
+		slog.Any("header", redactHeader(req.Header)),
+		slog.String("body", body),
+	)
+}
+
+func (c *tidyDNSClient) logResult(req *http.Request, attempt int, status int, latency time.Duration, err error) {
+	if c.logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.Path),
+		slog.String("request_id", req.Header.Get(headerRequestID)),
+		slog.Int("attempt", attempt),
+		slog.Int("status", status),
+		slog.Duration("latency", latency),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+		c.logger.LogAttrs(req.Context(), slog.LevelWarn, "tidydns request failed", attrs...)
+		return
+	}
+	c.logger.LogAttrs(req.Context(), slog.LevelInfo, "tidydns request", attrs...)
+}
+
+// logResponseBody logs the body of a response at debug level. The body is
+// buffered so it can still be read by the caller.
+func (c *tidyDNSClient) logResponseBody(req *http.Request, res *http.Response) {
+	if c.logger == nil || !c.logger.Enabled(req.Context(), slog.LevelDebug) {
+		return
+	}
+
+	b, err := io.ReadAll(res.Body)
+	res.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(b), res.Body), res.Body}
+	if err != nil {
+		return
+	}
+
+	c.logBody(req, string(b))
+}
+
+func (c *tidyDNSClient) logBody(req *http.Request, body string) {
+	if c.logger == nil || !c.logger.Enabled(req.Context(), slog.LevelDebug) {
+		return
+	}
+
+	c.logger.LogAttrs(req.Context(), slog.LevelDebug, "tidydns response",
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.Path),
+		slog.String("request_id", req.Header.Get(headerRequestID)),
+		slog.String("body", redactJSON(body)),
+	)
+}
+
+func redactHeader(header http.Header) http.Header {
+	h := header.Clone()
+	if h.Get("Authorization") != "" {
+		h.Set("Authorization", redacted)
+	}
+	return h
+}
+
+func redactForm(body string) string {
+	values, err := url.ParseQuery(body)
+	if err != nil {
+		return redacted
+	}
+	for _, field := range sensitiveFields {
+		if values.Has(field) {
+			values.Set(field, redacted)
+		}
+	}
+	return values.Encode()
+}
+
+func redactJSON(body string) string {
+	return sensitiveJSON.ReplaceAllString(body, `$1"`+redacted+`"`)
+}
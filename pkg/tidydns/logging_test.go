@@ -0,0 +1,92 @@
+package tidydns
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeLogLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &entry))
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+func TestLoggerRedactsSecrets(t *testing.T) {
+	var requestID string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requestID = req.Header.Get(headerRequestID)
+		_, _ = rw.Write([]byte(`{"data":{"id":144},"status":"0","epassword":"secret-password"}`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c, err := NewWithOptions(server.URL, "api-user", "api-password", WithLogger(logger))
+	assert.NoError(t, err)
+
+	_, err = c.CreateInternalUser(context.Background(), "test_user", "secret-password", "", false, AuthGroupUser, nil)
+	assert.NoError(t, err)
+
+	output := buf.String()
+	assert.NotContains(t, output, "secret-password")
+	assert.NotContains(t, output, "api-password")
+	assert.NotContains(t, output, base64.StdEncoding.EncodeToString([]byte("api-user:api-password")))
+	assert.Contains(t, output, "test_user")
+
+	lines := decodeLogLines(t, &buf)
+	assert.Len(t, lines, 3)
+	assert.Equal(t, "tidydns request", lines[1]["msg"])
+	assert.Equal(t, "INFO", lines[1]["level"])
+	assert.Equal(t, "POST", lines[1]["method"])
+	assert.Equal(t, "/=/user/new", lines[1]["path"])
+	assert.Equal(t, float64(200), lines[1]["status"])
+	assert.Contains(t, lines[1], "latency")
+	assert.NotEmpty(t, requestID)
+	for _, line := range lines {
+		assert.Equal(t, requestID, line["request_id"])
+	}
+}
+
+func TestLoggerFailedRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusInternalServerError)
+		_, _ = rw.Write([]byte(`{"error":"name is invalid"}`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	c, err := NewWithOptions(server.URL, "username", "password", WithLogger(logger))
+	assert.NoError(t, err)
+
+	_, err = c.UpdateDHCPInterfaceName(context.Background(), 30641, "bad name")
+	assert.Error(t, err)
+
+	lines := decodeLogLines(t, &buf)
+	assert.Len(t, lines, 1)
+	assert.Equal(t, "WARN", lines[0]["level"])
+	assert.Equal(t, float64(500), lines[0]["status"])
+	assert.Contains(t, lines[0]["error"], "name is invalid")
+}
+
+func TestRedactForm(t *testing.T) {
+	assert.Equal(t, "epassword=%5BREDACTED%5D&epassword_verify=%5BREDACTED%5D&username=u", redactForm("username=u&epassword=p&epassword_verify=p"))
+	assert.Equal(t, "name=x", redactForm("name=x"))
+}
+
+func TestRedactJSON(t *testing.T) {
+	assert.Equal(t, `{"epassword": "[REDACTED]","name":"x"}`, redactJSON(`{"epassword": "se\"cret","name":"x"}`))
+}
@@ -0,0 +1,62 @@
+package tidydns
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// MetricsHook receives measurements of client method calls. It allows
+// metrics to be exported to any backend without this package depending on
+// it. Implementations must be safe for concurrent use.
+type MetricsHook interface {
+	// OperationStarted is called when a client method is called.
+	OperationStarted(operation string)
+	// OperationFinished is called when a client method returns, with the
+	// error it returned.
+	OperationFinished(operation string, duration time.Duration, err error)
+}
+
+// WithMetricsHook reports every client method call to the given hook. It
+// may be given more than once to report to several hooks. Methods called by
+// other methods, such as GetFreeIP by AllocateInterface, are reported only
+// as part of the outer call.
+func WithMetricsHook(hook MetricsHook) Option {
+	return func(c *config) {
+		c.metricsHooks = append(c.metricsHooks, hook)
+	}
+}
+
+// ErrorClass classifies an error returned by the client for use as a low
+// cardinality metric label. It returns one of "canceled", "timeout",
+// "not_found", "unauthorized", "forbidden", "already_exists", "conflict",
+// "invalid_argument", "server_error", "client_error" or "other".
+func ErrorClass(err error) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, ErrNotFound):
+		return "not_found"
+	case errors.Is(err, ErrUnauthorized):
+		return "unauthorized"
+	case errors.Is(err, ErrForbidden):
+		return "forbidden"
+	case errors.Is(err, ErrAlreadyExists):
+		return "already_exists"
+	case errors.Is(err, ErrConflict):
+		return "conflict"
+	case errors.Is(err, ErrInvalidArgument):
+		return "invalid_argument"
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.StatusCode >= 500 {
+			return "server_error"
+		}
+		return "client_error"
+	}
+	return "other"
+}
@@ -0,0 +1,90 @@
+package tidydns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingHook struct {
+	mu       sync.Mutex
+	started  []string
+	finished []string
+	errs     []error
+}
+
+func (h *recordingHook) OperationStarted(operation string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.started = append(h.started, operation)
+}
+
+func (h *recordingHook) OperationFinished(operation string, duration time.Duration, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.finished = append(h.finished, operation)
+	h.errs = append(h.errs, err)
+}
+
+func TestMetricsHook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method == "DELETE" {
+			rw.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = rw.Write([]byte(readRecordListResponse))
+	}))
+	defer server.Close()
+
+	hook := &recordingHook{}
+	c, err := NewWithOptions(server.URL, "username", "password", WithMetricsHook(hook))
+	assert.NoError(t, err)
+
+	_, err = c.CreateRecord(context.Background(), 2861, RecordInfo{
+		Type:        RecordTypeA,
+		Name:        "tal-test",
+		Destination: "10.68.1.2",
+	})
+	assert.NoError(t, err)
+	err = c.DeleteRecord(context.Background(), 2861, 64694)
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{"CreateRecord", "DeleteRecord"}, hook.started)
+	assert.Equal(t, []string{"CreateRecord", "DeleteRecord"}, hook.finished)
+	assert.NoError(t, hook.errs[0])
+	assert.ErrorIs(t, hook.errs[1], ErrForbidden)
+}
+
+func TestMetricsHookNestedCalls(t *testing.T) {
+	var created []string
+	server := allocateServer(t, map[string]bool{"10.68.0.129": true}, &created)
+	defer server.Close()
+
+	hook := &recordingHook{}
+	c, err := NewWithOptions(server.URL, "username", "password", WithMetricsHook(hook))
+	assert.NoError(t, err)
+
+	_, err = c.AllocateInterface(context.Background(), 1185, "node1", AllocateOptions{Backoff: time.Millisecond})
+	assert.NoError(t, err)
+	_, err = c.GetFreeIP(context.Background(), 1185)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"AllocateInterface", "GetFreeIP"}, hook.started)
+	assert.Equal(t, []string{"AllocateInterface", "GetFreeIP"}, hook.finished)
+}
+
+func TestErrorClass(t *testing.T) {
+	assert.Equal(t, "not_found", ErrorClass(&APIError{StatusCode: http.StatusNotFound}))
+	assert.Equal(t, "already_exists", ErrorClass(&APIError{StatusCode: 500, Body: "Key (name)=(x) already exists"}))
+	assert.Equal(t, "client_error", ErrorClass(&APIError{StatusCode: http.StatusBadRequest}))
+	assert.Equal(t, "timeout", ErrorClass(context.DeadlineExceeded))
+	assert.Equal(t, "invalid_argument", ErrorClass(fmt.Errorf("%w: bad", ErrInvalidArgument)))
+	assert.Equal(t, "other", ErrorClass(errors.New("boom")))
+}
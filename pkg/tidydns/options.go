@@ -0,0 +1,127 @@
+package tidydns
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Option configures a client created by NewWithOptions.
+type Option func(*config)
+
+type config struct {
+	client     *http.Client
+	transport  http.RoundTripper
+	timeout    time.Duration
+	hasTimeout bool
+	rootCAs    *x509.CertPool
+	certs      []tls.Certificate
+	proxy      func(*http.Request) (*url.URL, error)
+	userAgent  string
+	retry      RetryPolicy
+	limiter    *limiter
+	inFlight   chan struct{}
+	logger     *slog.Logger
+
+	metricsHooks  []MetricsHook
+	ptrRecordType *RecordType
+}
+
+// WithHTTPClient uses the given HTTP client as the base for all requests.
+// The client is copied, so later options do not modify the caller's value.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *config) {
+		c.client = client
+	}
+}
+
+// WithTransport sets the round tripper used to send requests.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *config) {
+		c.transport = transport
+	}
+}
+
+// WithTimeout sets the overall time limit for a single HTTP request.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.timeout = timeout
+		c.hasTimeout = true
+	}
+}
+
+// WithRootCAs sets the certificate authorities used to verify the server.
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(c *config) {
+		c.rootCAs = pool
+	}
+}
+
+// WithClientCertificate adds a certificate presented to the server for
+// mutual TLS.
+func WithClientCertificate(cert tls.Certificate) Option {
+	return func(c *config) {
+		c.certs = append(c.certs, cert)
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) Option {
+	return func(c *config) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithProxy routes all requests through the given proxy.
+func WithProxy(proxyURL *url.URL) Option {
+	return func(c *config) {
+		c.proxy = http.ProxyURL(proxyURL)
+	}
+}
+
+func (c *config) httpClient() (*http.Client, error) {
+	client := &http.Client{}
+	if c.client != nil {
+		*client = *c.client
+	}
+
+	if c.transport != nil {
+		client.Transport = c.transport
+	}
+
+	if c.rootCAs != nil || len(c.certs) > 0 || c.proxy != nil {
+		rt := client.Transport
+		if rt == nil {
+			rt = http.DefaultTransport
+		}
+		base, ok := rt.(*http.Transport)
+		if !ok {
+			return nil, fmt.Errorf("TLS and proxy options require an *http.Transport, got %T", rt)
+		}
+
+		transport := base.Clone()
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		if c.rootCAs != nil {
+			transport.TLSClientConfig.RootCAs = c.rootCAs
+		}
+		if len(c.certs) > 0 {
+			transport.TLSClientConfig.Certificates = append(transport.TLSClientConfig.Certificates, c.certs...)
+		}
+		if c.proxy != nil {
+			transport.Proxy = c.proxy
+		}
+		client.Transport = transport
+	}
+
+	if c.hasTimeout {
+		client.Timeout = c.timeout
+	}
+
+	return client, nil
+}
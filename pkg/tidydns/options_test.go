@@ -0,0 +1,102 @@
+package tidydns
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestNewWithOptionsUserAgent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		username, password, ok := req.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "username", username)
+		assert.Equal(t, "password", password)
+		assert.Equal(t, "tidydns-test/1.0", req.UserAgent())
+		_, _ = rw.Write([]byte(freeIPResponse))
+	}))
+	defer server.Close()
+
+	c, err := NewWithOptions(server.URL, "username", "password", WithUserAgent("tidydns-test/1.0"))
+	assert.NoError(t, err)
+	ip, err := c.GetFreeIP(context.Background(), 1185)
+	assert.NoError(t, err)
+	assert.Equal(t, "10.68.0.134", ip)
+}
+
+func TestNewWithOptionsTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		_, _ = rw.Write([]byte(freeIPResponse))
+	}))
+	defer server.Close()
+
+	c, err := NewWithOptions(server.URL, "username", "password", WithTimeout(50*time.Millisecond))
+	assert.NoError(t, err)
+	_, err = c.GetFreeIP(context.Background(), 1185)
+	assert.Error(t, err)
+}
+
+func TestNewWithOptionsRootCAs(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte(freeIPResponse))
+	}))
+	defer server.Close()
+
+	c, err := NewWithOptions(server.URL, "username", "password")
+	assert.NoError(t, err)
+	_, err = c.GetFreeIP(context.Background(), 1185)
+	assert.Error(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	c, err = NewWithOptions(server.URL, "username", "password", WithRootCAs(pool))
+	assert.NoError(t, err)
+	ip, err := c.GetFreeIP(context.Background(), 1185)
+	assert.NoError(t, err)
+	assert.Equal(t, "10.68.0.134", ip)
+}
+
+func TestNewWithOptionsTransport(t *testing.T) {
+	called := false
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		called = true
+		return http.DefaultTransport.RoundTrip(req)
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte(freeIPResponse))
+	}))
+	defer server.Close()
+
+	httpClient := &http.Client{}
+	c, err := NewWithOptions(server.URL, "username", "password", WithHTTPClient(httpClient), WithTransport(transport))
+	assert.NoError(t, err)
+	_, err = c.GetFreeIP(context.Background(), 1185)
+	assert.NoError(t, err)
+	assert.True(t, called)
+	assert.Nil(t, httpClient.Transport)
+}
+
+func TestNewWithOptionsProxyRequiresHTTPTransport(t *testing.T) {
+	proxyURL, _ := url.Parse("http://proxy.example.com:3128")
+	transport := roundTripperFunc(http.DefaultTransport.RoundTrip)
+
+	_, err := NewWithOptions("http://tidydns.example.com", "username", "password", WithTransport(transport), WithProxy(proxyURL))
+	assert.Error(t, err)
+
+	_, err = NewWithOptions("http://tidydns.example.com", "username", "password", WithProxy(proxyURL))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,98 @@
+package tidydns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// defaultProvisionInterval is the time between polls of WaitForProvisioned
+// if no interval is given.
+const defaultProvisionInterval = 5 * time.Second
+
+// ErrNotProvisioned is matched by the errors of WaitForProvisioned when the
+// wait ends before the zone is provisioned.
+var ErrNotProvisioned = errors.New("zone not provisioned")
+
+// ProvisionError is returned by WaitForProvisioned when the wait ends before
+// the zone is provisioned, e.g. because the timeout expired. It matches
+// ErrNotProvisioned as well as the error that ended the wait.
+type ProvisionError struct {
+	// Zone is the zone as last read.
+	Zone *Zone
+	// Log is the provision log of the zone as last read, which usually
+	// tells why provisioning did not complete.
+	Log string
+	Err error
+}
+
+func (e *ProvisionError) Error() string {
+	return fmt.Sprintf("zone %d not provisioned: %v", e.Zone.ID, e.Err)
+}
+
+// Is reports whether target is ErrNotProvisioned.
+func (e *ProvisionError) Is(target error) bool {
+	return target == ErrNotProvisioned
+}
+
+func (e *ProvisionError) Unwrap() error {
+	return e.Err
+}
+
+// WaitOptions control how WaitForProvisioned polls a zone.
+type WaitOptions struct {
+	// Serial is the serial of the zone before the change being waited for.
+	// The wait ends once the zone is provisioned with a higher serial. If
+	// zero, only the provisioning state is checked.
+	Serial int
+	// Interval is the time between polls. It defaults to 5 seconds.
+	Interval time.Duration
+	// Timeout limits the total time spent waiting. Zero means the wait is
+	// only limited by the context.
+	Timeout time.Duration
+}
+
+// Provisioned reports whether a zone has been provisioned according to the
+// options, i.e. whether its serial is higher than Serial and it was
+// provisioned at or after it was last modified.
+func (o WaitOptions) Provisioned(zone *Zone) bool {
+	return zone.Serial > o.Serial && zone.Provisioned()
+}
+
+func (o WaitOptions) interval() time.Duration {
+	if o.Interval > 0 {
+		return o.Interval
+	}
+	return defaultProvisionInterval
+}
+
+func (c *tidyDNSClient) WaitForProvisioned(ctx context.Context, zoneID int, opts WaitOptions) (_ *Zone, err error) {
+	ctx, end := c.startOperation(ctx, "WaitForProvisioned", attrZoneID.Int(zoneID))
+	defer func() { end(err) }()
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	var last *Zone
+	for {
+		zone, err := c.GetZone(ctx, zoneID)
+		if err != nil {
+			if last == nil {
+				return nil, err
+			}
+			return last, &ProvisionError{Zone: last, Log: last.ProvisionLog, Err: err}
+		}
+		if opts.Provisioned(zone) {
+			return zone, nil
+		}
+		last = zone
+
+		if err := sleep(ctx, opts.interval()); err != nil {
+			return zone, &ProvisionError{Zone: zone, Log: zone.ProvisionLog, Err: err}
+		}
+	}
+}
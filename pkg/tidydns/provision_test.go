@@ -0,0 +1,125 @@
+package tidydns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// provisioningServer reports the zone with the given serial and provision
+// state for the n-th poll, repeating the last state.
+func provisioningServer(t *testing.T, states ...string) (*httptest.Server, *atomic.Int32) {
+	var polls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/=/zone/279", req.URL.Path)
+		n := int(polls.Add(1))
+		if n > len(states) {
+			n = len(states)
+		}
+		_, _ = rw.Write([]byte(states[n-1]))
+	}))
+	return server, &polls
+}
+
+// zoneState returns a zone modified at 13:38:36 that was provisioned after
+// the modification if provisioned is set and before it otherwise.
+func zoneState(serial int, provisioned bool, log string) string {
+	provisionDate := "2021-10-04 13:38:20"
+	if provisioned {
+		provisionDate = "2021-10-04 13:38:56"
+	}
+	return fmt.Sprintf(`{"id": 279, "name": "netic.dk", "serial": %d, "provision_state": 1, "provision_date": %q, "provision_log": %q, "modified_date": "2021-10-04 13:38:36", "server_state": "1"}`, serial, provisionDate, log)
+}
+
+func TestWaitForProvisioned(t *testing.T) {
+	server, polls := provisioningServer(t,
+		zoneState(17830, true, ""),
+		zoneState(17831, false, ""),
+		zoneState(17831, true, "done"),
+	)
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	zone, err := c.WaitForProvisioned(context.Background(), 279, WaitOptions{Serial: 17830, Interval: time.Millisecond})
+	assert.NoError(t, err)
+	assert.Equal(t, 17831, zone.Serial)
+	assert.Equal(t, "done", zone.ProvisionLog)
+	assert.Equal(t, 1, zone.ServerState)
+	assert.Equal(t, int32(3), polls.Load())
+}
+
+func TestWaitForProvisionedTimeout(t *testing.T) {
+	server, _ := provisioningServer(t, zoneState(17831, false, "named-checkzone failed"))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	zone, err := c.WaitForProvisioned(context.Background(), 279, WaitOptions{
+		Serial:   17830,
+		Interval: time.Millisecond,
+		Timeout:  20 * time.Millisecond,
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorIs(t, err, ErrNotProvisioned)
+	var provisionErr *ProvisionError
+	if assert.ErrorAs(t, err, &provisionErr) {
+		assert.Equal(t, "named-checkzone failed", provisionErr.Log)
+		assert.Equal(t, zone, provisionErr.Zone)
+	}
+	if assert.NotNil(t, zone) {
+		assert.Equal(t, 17831, zone.Serial)
+	}
+}
+
+func TestWaitForProvisionedError(t *testing.T) {
+	var polls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if polls.Add(1) > 1 {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = rw.Write([]byte(zoneState(17831, false, "")))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	zone, err := c.WaitForProvisioned(context.Background(), 279, WaitOptions{Serial: 17830, Interval: time.Millisecond})
+	assert.ErrorIs(t, err, ErrNotProvisioned)
+	var apiErr *APIError
+	assert.ErrorAs(t, err, &apiErr)
+	if assert.NotNil(t, zone) {
+		assert.Equal(t, 17831, zone.Serial)
+	}
+
+	server.Close()
+	zone, err = c.WaitForProvisioned(context.Background(), 279, WaitOptions{})
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotProvisioned)
+	assert.Nil(t, zone)
+}
+
+func TestWaitOptionsProvisioned(t *testing.T) {
+	modified := time.Date(2021, 10, 4, 13, 38, 36, 0, time.UTC)
+	assert.True(t, WaitOptions{}.Provisioned(&Zone{Serial: 1, ModifiedDate: modified, ProvisionDate: modified}))
+	assert.False(t, WaitOptions{Serial: 1}.Provisioned(&Zone{Serial: 1, ModifiedDate: modified, ProvisionDate: modified}))
+	assert.False(t, WaitOptions{}.Provisioned(&Zone{Serial: 1, ModifiedDate: modified, ProvisionDate: modified.Add(-time.Second)}))
+	assert.False(t, WaitOptions{}.Provisioned(&Zone{Serial: 1, ModifiedDate: modified}))
+}
+
+func TestWaitOptionsProvisionedFixture(t *testing.T) {
+	// The zone was provisioned two seconds after it was last modified.
+	var zones []zoneRead
+	assert.NoError(t, json.Unmarshal([]byte(zoneSearchResponse), &zones))
+	assert.Len(t, zones, 1)
+	zone, err := zones[0].zone()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, zone.ProvisionState)
+	assert.True(t, WaitOptions{Serial: 7}.Provisioned(zone))
+	assert.False(t, WaitOptions{Serial: 8}.Provisioned(zone))
+}
@@ -0,0 +1,124 @@
+package tidydns
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// WithRateLimit limits the client to requestsPerSecond HTTP requests on
+// average, allowing bursts of up to burst requests. Retries count as
+// separate requests.
+func WithRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(c *config) {
+		if requestsPerSecond <= 0 {
+			c.limiter = nil
+			return
+		}
+		if burst < 1 {
+			burst = 1
+		}
+		c.limiter = &limiter{
+			rate:   requestsPerSecond,
+			burst:  float64(burst),
+			tokens: float64(burst),
+		}
+	}
+}
+
+// WithMaxInFlight limits the number of HTTP requests the client has in
+// flight at the same time. A request stays in flight until its response
+// body has been closed.
+func WithMaxInFlight(n int) Option {
+	return func(c *config) {
+		if n <= 0 {
+			c.inFlight = nil
+			return
+		}
+		c.inFlight = make(chan struct{}, n)
+	}
+}
+
+// throttle combines the rate limiter and the in-flight cap. A zero value
+// does not throttle.
+type throttle struct {
+	limiter  *limiter
+	inFlight chan struct{}
+}
+
+// acquire blocks until a request may be sent. The returned function must be
+// called once the request has completed.
+func (t throttle) acquire(ctx context.Context) (func(), error) {
+	if t.inFlight != nil {
+		select {
+		case t.inFlight <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	release := func() {
+		if t.inFlight != nil {
+			<-t.inFlight
+		}
+	}
+
+	if t.limiter != nil {
+		if err := t.limiter.wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+
+	return release, nil
+}
+
+// limiter is a token bucket refilled at a constant rate.
+type limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// wait takes a token from the bucket, blocking until one is available.
+// Tokens are reserved in arrival order, so waiting callers are served
+// fairly.
+func (l *limiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	if !l.last.IsZero() {
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+	l.tokens--
+	tokens := l.tokens
+	l.mu.Unlock()
+
+	if tokens >= 0 {
+		return nil
+	}
+
+	delay := time.Duration(-tokens / l.rate * float64(time.Second))
+	if err := sleep(ctx, delay); err != nil {
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// releaseBody calls release once the response body is closed.
+type releaseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,103 @@
+package tidydns
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxInFlight(t *testing.T) {
+	var current, peak atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		n := current.Add(1)
+		defer current.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		_, _ = rw.Write([]byte(freeIPResponse))
+	}))
+	defer server.Close()
+
+	c, err := NewWithOptions(server.URL, "username", "password", WithMaxInFlight(2))
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.GetFreeIP(context.Background(), 1185)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(2), peak.Load())
+}
+
+func TestMaxInFlightCreateRecord(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte(readRecordListResponse))
+	}))
+	defer server.Close()
+
+	c, err := NewWithOptions(server.URL, "username", "password", WithMaxInFlight(1))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	id, err := c.CreateRecord(ctx, 2861, RecordInfo{
+		Type:        RecordTypeA,
+		Name:        "tal-test",
+		Destination: "10.68.1.2",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 64694, id)
+}
+
+func TestRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte(freeIPResponse))
+	}))
+	defer server.Close()
+
+	c, err := NewWithOptions(server.URL, "username", "password", WithRateLimit(50, 1))
+	assert.NoError(t, err)
+
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		_, err := c.GetFreeIP(context.Background(), 1185)
+		assert.NoError(t, err)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+}
+
+func TestRateLimitContextCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte(freeIPResponse))
+	}))
+	defer server.Close()
+
+	c, err := NewWithOptions(server.URL, "username", "password", WithRateLimit(0.1, 1))
+	assert.NoError(t, err)
+
+	_, err = c.GetFreeIP(context.Background(), 1185)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = c.GetFreeIP(ctx, 1185)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
@@ -0,0 +1,395 @@
+package tidydns
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// RecordData is the structured content of records that hold more than a
+// single destination. It is one of MXData, SRVData, CAAData, TLSAData,
+// SSHFPData and DSData.
+//
+// TidyDNS stores the leading number of MX, SRV and CAA records in the value
+// field and the weight and port of SRV records in fields of their own. The
+// remaining content is stored in the destination in presentation format.
+type RecordData interface {
+	// RecordType returns the type of the records holding the data.
+	RecordType() RecordType
+	// Validate returns an error matching ErrInvalidArgument if the data
+	// cannot be stored in TidyDNS.
+	Validate() error
+
+	fields() recordFields
+}
+
+// recordFields are the fields of a TidyDNS record holding record data.
+type recordFields struct {
+	destination string
+	value       nullableInt
+	weight      nullableInt
+	port        nullableInt
+}
+
+// form adds the fields to a create or update request.
+func (f recordFields) form(data url.Values) {
+	data.Set("destination", f.destination)
+	for name, v := range map[string]nullableInt{"value": f.value, "weight": f.weight, "port": f.port} {
+		if v.Valid {
+			data.Set(name, strconv.Itoa(v.Int))
+		}
+	}
+}
+
+// fields returns the fields a record is stored in, validating its data if
+// set. Data is only checked against Type when creating records, as Type is
+// not used to update records.
+func (i RecordInfo) fields(create bool) (recordFields, error) {
+	if i.Data == nil {
+		return recordFields{destination: i.Destination}, nil
+	}
+	if create && i.Type != i.Data.RecordType() {
+		return recordFields{}, fmt.Errorf("%w: %s data for %s record", ErrInvalidArgument, i.Data.RecordType(), i.Type)
+	}
+	if err := i.Data.Validate(); err != nil {
+		return recordFields{}, err
+	}
+	return i.Data.fields(), nil
+}
+
+// decodeRecordData returns the structured data of a record read from
+// TidyDNS, or nil if the type has no structured data or the record content
+// cannot be parsed. Missing or out of range numeric fields make a record
+// undecodable rather than being read as a different number, which would
+// change the record when it is written back.
+func decodeRecordData(t RecordType, f recordFields) RecordData {
+	var data RecordData
+	switch t {
+	case RecordTypeMX:
+		preference, ok := uintField(f.value, 16)
+		if !ok {
+			return nil
+		}
+		data = MXData{Preference: uint16(preference), Exchange: f.destination}
+	case RecordTypeSRV:
+		priority, ok1 := uintField(f.value, 16)
+		weight, ok2 := uintField(f.weight, 16)
+		port, ok3 := uintField(f.port, 16)
+		if !ok1 || !ok2 || !ok3 {
+			return nil
+		}
+		data = SRVData{
+			Priority: uint16(priority),
+			Weight:   uint16(weight),
+			Port:     uint16(port),
+			Target:   f.destination,
+		}
+	case RecordTypeCAA:
+		flags, ok := uintField(f.value, 8)
+		tag, value, found := strings.Cut(f.destination, " ")
+		if !ok || !found {
+			return nil
+		}
+		data = CAAData{Flags: uint8(flags), Tag: tag, Value: unquoteString(value)}
+	case RecordTypeTLSA:
+		var d TLSAData
+		if !scanFields(f.destination, &d.Usage, &d.Selector, &d.MatchingType, &d.Certificate) {
+			return nil
+		}
+		data = d
+	case RecordTypeSSHFP:
+		var d SSHFPData
+		if !scanFields(f.destination, &d.Algorithm, &d.Type, &d.Fingerprint) {
+			return nil
+		}
+		data = d
+	case RecordTypeDS:
+		var d DSData
+		if !scanFields(f.destination, &d.KeyTag, &d.Algorithm, &d.DigestType, &d.Digest) {
+			return nil
+		}
+		data = d
+	default:
+		return nil
+	}
+	if data.Validate() != nil {
+		return nil
+	}
+	return data
+}
+
+// uintField returns a numeric field if it is set and fits in an unsigned
+// integer of the given number of bits.
+func uintField(v nullableInt, bits int) (int, bool) {
+	return v.Int, v.Valid && v.Int >= 0 && v.Int < 1<<bits
+}
+
+// MXData is the content of an MX record.
+type MXData struct {
+	Preference uint16
+	// Exchange is the host name of the mail server, or "." if the domain
+	// does not accept mail.
+	Exchange string
+}
+
+func (MXData) RecordType() RecordType { return RecordTypeMX }
+
+func (d MXData) Validate() error {
+	return validateHostname("MX exchange", d.Exchange)
+}
+
+func (d MXData) fields() recordFields {
+	return recordFields{destination: d.Exchange, value: validInt(int(d.Preference))}
+}
+
+// SRVData is the content of an SRV record.
+type SRVData struct {
+	Priority uint16
+	Weight   uint16
+	Port     uint16
+	// Target is the host name providing the service, or "." if the service
+	// is not available.
+	Target string
+}
+
+func (SRVData) RecordType() RecordType { return RecordTypeSRV }
+
+func (d SRVData) Validate() error {
+	return validateHostname("SRV target", d.Target)
+}
+
+func (d SRVData) fields() recordFields {
+	return recordFields{
+		destination: d.Target,
+		value:       validInt(int(d.Priority)),
+		weight:      validInt(int(d.Weight)),
+		port:        validInt(int(d.Port)),
+	}
+}
+
+// CAAFlagCritical marks a CAA property that certificate authorities must
+// understand to issue certificates.
+const CAAFlagCritical = 128
+
+// CAAData is the content of a CAA record.
+type CAAData struct {
+	Flags uint8
+	// Tag is the property, e.g. "issue", "issuewild" or "iodef".
+	Tag   string
+	Value string
+}
+
+func (CAAData) RecordType() RecordType { return RecordTypeCAA }
+
+func (d CAAData) Validate() error {
+	if d.Flags != 0 && d.Flags != CAAFlagCritical {
+		return fmt.Errorf("%w: CAA flags must be 0 or %d, not %d", ErrInvalidArgument, CAAFlagCritical, d.Flags)
+	}
+	if len(d.Tag) == 0 || len(d.Tag) > 15 {
+		return fmt.Errorf("%w: CAA tag must be 1 to 15 characters", ErrInvalidArgument)
+	}
+	for _, r := range d.Tag {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return fmt.Errorf("%w: CAA tag %q must be alphanumeric", ErrInvalidArgument, d.Tag)
+		}
+	}
+	return nil
+}
+
+func (d CAAData) fields() recordFields {
+	return recordFields{destination: d.Tag + " " + quoteString(d.Value), value: validInt(int(d.Flags))}
+}
+
+// TLSAData is the content of a TLSA record.
+type TLSAData struct {
+	// Usage is the certificate usage, 0 (PKIX-TA) to 3 (DANE-EE).
+	Usage uint8
+	// Selector is 0 for the full certificate and 1 for the public key.
+	Selector uint8
+	// MatchingType is 0 for the exact data, 1 for a SHA-256 and 2 for a
+	// SHA-512 hash.
+	MatchingType uint8
+	// Certificate is the hex encoded certificate association data.
+	Certificate string
+}
+
+func (TLSAData) RecordType() RecordType { return RecordTypeTLSA }
+
+func (d TLSAData) Validate() error {
+	if d.Usage > 3 {
+		return fmt.Errorf("%w: TLSA usage must be 0 to 3, not %d", ErrInvalidArgument, d.Usage)
+	}
+	if d.Selector > 1 {
+		return fmt.Errorf("%w: TLSA selector must be 0 or 1, not %d", ErrInvalidArgument, d.Selector)
+	}
+	sizes := map[uint8]int{0: 0, 1: 32, 2: 64}
+	size, ok := sizes[d.MatchingType]
+	if !ok {
+		return fmt.Errorf("%w: TLSA matching type must be 0 to 2, not %d", ErrInvalidArgument, d.MatchingType)
+	}
+	return validateHex("TLSA certificate data", d.Certificate, size)
+}
+
+func (d TLSAData) fields() recordFields {
+	return recordFields{destination: fmt.Sprintf("%d %d %d %s", d.Usage, d.Selector, d.MatchingType, d.Certificate)}
+}
+
+// SSHFPData is the content of an SSHFP record.
+type SSHFPData struct {
+	// Algorithm is the key algorithm: 1 (RSA), 2 (DSA), 3 (ECDSA),
+	// 4 (Ed25519) or 6 (Ed448).
+	Algorithm uint8
+	// Type is the fingerprint type, 1 for SHA-1 and 2 for SHA-256.
+	Type uint8
+	// Fingerprint is the hex encoded fingerprint.
+	Fingerprint string
+}
+
+func (SSHFPData) RecordType() RecordType { return RecordTypeSSHFP }
+
+func (d SSHFPData) Validate() error {
+	switch d.Algorithm {
+	case 1, 2, 3, 4, 6:
+	default:
+		return fmt.Errorf("%w: unknown SSHFP algorithm %d", ErrInvalidArgument, d.Algorithm)
+	}
+	sizes := map[uint8]int{1: 20, 2: 32}
+	size, ok := sizes[d.Type]
+	if !ok {
+		return fmt.Errorf("%w: unknown SSHFP fingerprint type %d", ErrInvalidArgument, d.Type)
+	}
+	return validateHex("SSHFP fingerprint", d.Fingerprint, size)
+}
+
+func (d SSHFPData) fields() recordFields {
+	return recordFields{destination: fmt.Sprintf("%d %d %s", d.Algorithm, d.Type, d.Fingerprint)}
+}
+
+// DSData is the content of a DS record.
+type DSData struct {
+	KeyTag    uint16
+	Algorithm uint8
+	// DigestType is 1 for SHA-1, 2 for SHA-256 and 4 for SHA-384.
+	DigestType uint8
+	// Digest is the hex encoded digest of the DNSKEY.
+	Digest string
+}
+
+func (DSData) RecordType() RecordType { return RecordTypeDS }
+
+func (d DSData) Validate() error {
+	if d.Algorithm == 0 {
+		return fmt.Errorf("%w: DS algorithm must not be 0", ErrInvalidArgument)
+	}
+	sizes := map[uint8]int{1: 20, 2: 32, 4: 48}
+	size, ok := sizes[d.DigestType]
+	if !ok {
+		return fmt.Errorf("%w: unknown DS digest type %d", ErrInvalidArgument, d.DigestType)
+	}
+	return validateHex("DS digest", d.Digest, size)
+}
+
+func (d DSData) fields() recordFields {
+	return recordFields{destination: fmt.Sprintf("%d %d %d %s", d.KeyTag, d.Algorithm, d.DigestType, d.Digest)}
+}
+
+// nullableInt is an integer field TidyDNS may report as null or as a
+// string.
+type nullableInt struct {
+	Int   int
+	Valid bool
+}
+
+func validInt(n int) nullableInt {
+	return nullableInt{Int: n, Valid: true}
+}
+
+func (n *nullableInt) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" || s == "" {
+		*n = nullableInt{}
+		return nil
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("invalid integer: %s", data)
+	}
+	*n = validInt(i)
+	return nil
+}
+
+func validateHostname(field, name string) error {
+	if name == "." {
+		return nil
+	}
+	name = strings.TrimSuffix(name, ".")
+	if name == "" || len(name) > 253 {
+		return fmt.Errorf("%w: invalid %s %q", ErrInvalidArgument, field, name)
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return fmt.Errorf("%w: invalid %s %q", ErrInvalidArgument, field, name)
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+				return fmt.Errorf("%w: invalid %s %q", ErrInvalidArgument, field, name)
+			}
+		}
+	}
+	return nil
+}
+
+// validateHex checks that s is hex encoded data of the given size in
+// bytes, or of any non-zero size if size is 0.
+func validateHex(field, s string, size int) error {
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return fmt.Errorf("%w: %s must be hex encoded", ErrInvalidArgument, field)
+	}
+	if size != 0 && len(b) != size {
+		return fmt.Errorf("%w: %s must be %d bytes, not %d", ErrInvalidArgument, field, size, len(b))
+	}
+	return nil
+}
+
+// scanFields parses space separated fields into the given uint8, uint16 and
+// string pointers.
+func scanFields(s string, dst ...any) bool {
+	fields := strings.Fields(s)
+	if len(fields) != len(dst) {
+		return false
+	}
+	for i, f := range fields {
+		switch d := dst[i].(type) {
+		case *uint8:
+			n, err := strconv.ParseUint(f, 10, 8)
+			if err != nil {
+				return false
+			}
+			*d = uint8(n)
+		case *uint16:
+			n, err := strconv.ParseUint(f, 10, 16)
+			if err != nil {
+				return false
+			}
+			*d = uint16(n)
+		case *string:
+			*d = f
+		}
+	}
+	return true
+}
+
+// quoteString quotes s as a character string in presentation format.
+func quoteString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func unquoteString(s string) string {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s
+	}
+	return strings.NewReplacer(`\\`, `\`, `\"`, `"`).Replace(s[1 : len(s)-1])
+}
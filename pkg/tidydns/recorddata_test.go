@@ -0,0 +1,212 @@
+package tidydns
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	sha1Hex   = "0123456789abcdef0123456789abcdef01234567"
+	sha256Hex = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+func TestRecordDataValidate(t *testing.T) {
+	valid := []RecordData{
+		MXData{Preference: 10, Exchange: "mail.example.com."},
+		MXData{Exchange: "."},
+		SRVData{Priority: 10, Weight: 5, Port: 443, Target: "_x.example.com"},
+		CAAData{Tag: "issue", Value: "letsencrypt.org"},
+		CAAData{Flags: CAAFlagCritical, Tag: "iodef", Value: "mailto:ca@example.com"},
+		TLSAData{Usage: 3, Selector: 1, MatchingType: 1, Certificate: sha256Hex},
+		TLSAData{Usage: 0, Selector: 0, MatchingType: 0, Certificate: "3082"},
+		SSHFPData{Algorithm: 4, Type: 2, Fingerprint: sha256Hex},
+		SSHFPData{Algorithm: 1, Type: 1, Fingerprint: strings.ToUpper(sha1Hex)},
+		DSData{KeyTag: 12345, Algorithm: 13, DigestType: 2, Digest: sha256Hex},
+	}
+	for _, d := range valid {
+		assert.NoError(t, d.Validate(), "%#v", d)
+	}
+
+	invalid := []RecordData{
+		MXData{Preference: 10},
+		MXData{Exchange: "mail..example.com"},
+		MXData{Exchange: "-mail.example.com"},
+		SRVData{Target: "has space.example.com"},
+		CAAData{Flags: 1, Tag: "issue"},
+		CAAData{Tag: ""},
+		CAAData{Tag: "is-sue"},
+		TLSAData{Usage: 4, Certificate: sha256Hex},
+		TLSAData{Selector: 2, Certificate: sha256Hex},
+		TLSAData{MatchingType: 3, Certificate: sha256Hex},
+		TLSAData{MatchingType: 2, Certificate: sha256Hex},
+		TLSAData{Certificate: "xyz"},
+		SSHFPData{Algorithm: 5, Type: 1, Fingerprint: sha1Hex},
+		SSHFPData{Algorithm: 1, Type: 3, Fingerprint: sha1Hex},
+		SSHFPData{Algorithm: 1, Type: 2, Fingerprint: sha1Hex},
+		DSData{Algorithm: 0, DigestType: 2, Digest: sha256Hex},
+		DSData{Algorithm: 8, DigestType: 3, Digest: sha256Hex},
+		DSData{Algorithm: 8, DigestType: 1, Digest: sha256Hex},
+	}
+	for _, d := range invalid {
+		assert.ErrorIs(t, d.Validate(), ErrInvalidArgument, "%#v", d)
+	}
+}
+
+func TestRecordDataRoundTrip(t *testing.T) {
+	data := []RecordData{
+		MXData{Preference: 10, Exchange: "mail.example.com."},
+		SRVData{Priority: 10, Weight: 5, Port: 443, Target: "web.example.com."},
+		CAAData{Flags: CAAFlagCritical, Tag: "issue", Value: `letsencrypt.org; "quoted"`},
+		TLSAData{Usage: 3, Selector: 1, MatchingType: 1, Certificate: sha256Hex},
+		SSHFPData{Algorithm: 4, Type: 2, Fingerprint: sha256Hex},
+		DSData{KeyTag: 12345, Algorithm: 13, DigestType: 2, Digest: sha256Hex},
+	}
+	for _, d := range data {
+		assert.Equal(t, d, decodeRecordData(d.RecordType(), d.fields()))
+	}
+
+	for _, f := range []struct {
+		t RecordType
+		f recordFields
+	}{
+		{RecordTypeA, recordFields{destination: "10.0.0.1"}},
+		{RecordTypeTLSA, recordFields{destination: "3 1 1"}},
+		{RecordTypeCAA, recordFields{destination: "issue", value: validInt(0)}},
+		{RecordTypeMX, recordFields{destination: "mail.example.com."}},
+		{RecordTypeMX, recordFields{destination: "mail.example.com.", value: validInt(65536)}},
+		{RecordTypeSRV, recordFields{destination: "web.example.com.", value: validInt(10), port: validInt(443)}},
+		{RecordTypeSRV, recordFields{destination: "web.example.com.", value: validInt(10), weight: validInt(5), port: validInt(-1)}},
+		{RecordTypeCAA, recordFields{destination: `issue "letsencrypt.org"`}},
+		{RecordTypeCAA, recordFields{destination: `issue "letsencrypt.org"`, value: validInt(256)}},
+	} {
+		assert.Nil(t, decodeRecordData(f.t, f.f), "%v %#v", f.t, f.f)
+	}
+}
+
+func TestCreateRecordData(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost {
+			assert.NoError(t, req.ParseForm())
+			assert.Equal(t, "6", req.PostForm.Get("type"))
+			assert.Equal(t, "web.example.com.", req.PostForm.Get("destination"))
+			assert.Equal(t, "10", req.PostForm.Get("value"))
+			assert.Equal(t, "5", req.PostForm.Get("weight"))
+			assert.Equal(t, "443", req.PostForm.Get("port"))
+			_, _ = rw.Write([]byte(createResponse))
+			return
+		}
+		_, _ = rw.Write([]byte(`[
+  {"id": 1, "type": 6, "type_name": "SRV", "name": "_https._tcp", "destination": "old.example.com.", "value": 10, "weight": 5, "port": 443},
+  {"id": 2, "type": 6, "type_name": "SRV", "name": "_https._tcp", "destination": "web.example.com.", "value": "10", "weight": "5", "port": "443"}
+]`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	id, err := c.CreateRecord(context.Background(), 2861, RecordInfo{
+		Type: RecordTypeSRV,
+		Name: "_https._tcp",
+		Data: SRVData{Priority: 10, Weight: 5, Port: 443, Target: "web.example.com."},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, id)
+}
+
+func TestCreateRecordInvalidData(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		t.Error("unexpected request")
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	_, err := c.CreateRecord(context.Background(), 2861, RecordInfo{
+		Type: RecordTypeA,
+		Name: "www",
+		Data: MXData{Preference: 10, Exchange: "mail.example.com."},
+	})
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+
+	err = c.UpdateRecord(context.Background(), 2861, 1, RecordInfo{Data: MXData{Preference: 10}})
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+}
+
+func TestUpdateRecordData(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.NoError(t, req.ParseForm())
+		assert.Equal(t, `issue "letsencrypt.org"`, req.PostForm.Get("destination"))
+		assert.Equal(t, "0", req.PostForm.Get("value"))
+		assert.False(t, req.PostForm.Has("port"))
+		_, _ = rw.Write([]byte(createResponse))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	err := c.UpdateRecord(context.Background(), 2861, 1, RecordInfo{
+		Destination: "ignored",
+		Data:        CAAData{Tag: "issue", Value: "letsencrypt.org"},
+	})
+	assert.NoError(t, err)
+}
+
+func TestReadRecordData(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte(`{"id": 1, "type": 3, "type_name": "MX", "name": ".", "destination": "mail.example.com.", "value": 20, "weight": null, "port": null, "status": 0}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	record, err := c.ReadRecord(context.Background(), 2861, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "mail.example.com.", record.Destination)
+	assert.Equal(t, MXData{Preference: 20, Exchange: "mail.example.com."}, record.Data)
+}
+
+func TestReadRecordDataNull(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/=/record_merged" {
+			_, _ = rw.Write([]byte(`[
+  {"id": 1, "type": 3, "type_name": "MX", "name": ".", "destination": "mail.example.com.", "value": null, "status": "0"},
+  {"id": 2, "type": 3, "type_name": "MX", "name": ".", "destination": "backup.example.com.", "value": 20, "status": "0"}
+]`))
+			return
+		}
+		_, _ = rw.Write([]byte(`{"id": 1, "type": 3, "type_name": "MX", "name": ".", "destination": "mail.example.com.", "value": null, "status": 0}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	record, err := c.ReadRecord(context.Background(), 2861, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, RecordTypeMX, record.Type)
+	assert.Equal(t, "mail.example.com.", record.Destination)
+	assert.Nil(t, record.Data)
+
+	records, err := c.ListRecords(context.Background(), 2861)
+	assert.NoError(t, err)
+	if assert.Len(t, records, 2) {
+		assert.Nil(t, records[0].Data)
+		assert.Equal(t, MXData{Preference: 20, Exchange: "backup.example.com."}, records[1].Data)
+	}
+}
+
+func TestListRecordsData(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte(`[
+  {"id": 1, "type": 9, "type_name": "TLSA", "name": "_443._tcp", "destination": "3 1 1 ` + sha256Hex + `", "value": null, "status": "0"},
+  {"id": 2, "type": 0, "type_name": "A", "name": "www", "destination": "10.0.0.1", "value": 0, "status": "0"}
+]`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	records, err := c.ListRecords(context.Background(), 2861)
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, TLSAData{Usage: 3, Selector: 1, MatchingType: 1, Certificate: sha256Hex}, records[0].Data)
+	assert.Nil(t, records[1].Data)
+}
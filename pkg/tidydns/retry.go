@@ -0,0 +1,164 @@
+package tidydns
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// defaultMinBackoff is the delay before the first retry if a policy gives
+// no minimum backoff.
+const defaultMinBackoff = 200 * time.Millisecond
+
+// RetryPolicy controls how requests failing with transient errors are
+// retried. Connection errors and 429, 502, 503 and 504 responses are
+// considered transient.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts per request, including
+	// the first one. Values below 2 disable retries.
+	MaxAttempts int
+	// MinBackoff is the delay before the first retry. The delay doubles for
+	// every following attempt, with random jitter applied. It defaults to
+	// 200ms.
+	MinBackoff time.Duration
+	// MaxBackoff caps the computed delay. A Retry-After header sent by the
+	// server takes precedence when it asks for a longer delay.
+	MaxBackoff time.Duration
+	// RetryNonIdempotent enables retries of POST requests. Only enable this
+	// if duplicate creations are acceptable or detected by the caller.
+	RetryNonIdempotent bool
+	// OnRetry is called before waiting for each retry.
+	OnRetry func(RetryEvent)
+}
+
+// RetryEvent describes a failed attempt that is about to be retried.
+type RetryEvent struct {
+	Method string
+	Path   string
+	// Attempt is the number of the attempt that failed, starting at 1.
+	Attempt int
+	// StatusCode is the HTTP status of the failed attempt, or 0 if no
+	// response was received.
+	StatusCode int
+	Err        error
+	// Delay is the time waited before the next attempt.
+	Delay time.Duration
+}
+
+// DefaultRetryPolicy returns a policy suitable for most callers: up to four
+// attempts of idempotent requests with backoff between 200ms and 5s.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 4,
+		MinBackoff:  defaultMinBackoff,
+		MaxBackoff:  5 * time.Second,
+	}
+}
+
+// WithRetryPolicy enables retries of transient failures.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *config) {
+		c.retry = policy
+	}
+}
+
+// backoff decides whether a failed attempt should be retried and how long to
+// wait before doing so.
+func (p RetryPolicy) backoff(req *http.Request, attempt int, err error) (time.Duration, bool) {
+	if err == nil || attempt >= p.MaxAttempts {
+		return 0, false
+	}
+	if !p.RetryNonIdempotent && !isIdempotent(req.Method) {
+		return 0, false
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return 0, false
+	}
+	if req.Context().Err() != nil {
+		return 0, false
+	}
+
+	var retryAfter time.Duration
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			retryAfter = apiErr.RetryAfter
+		default:
+			return 0, false
+		}
+	}
+
+	minBackoff := p.MinBackoff
+	if minBackoff <= 0 {
+		minBackoff = defaultMinBackoff
+	}
+	delay := p.MaxBackoff
+	if shift := attempt - 1; shift < 32 {
+		if d := minBackoff << shift; d > 0 && (p.MaxBackoff <= 0 || d < p.MaxBackoff) {
+			delay = d
+		}
+	}
+	if delay > 0 {
+		delay = delay/2 + rand.N(delay/2+1)
+	}
+	if retryAfter > delay {
+		delay = retryAfter
+	}
+
+	return delay, true
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as
+// an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// rewind prepares a request for another attempt by replacing its body.
+func rewind(req *http.Request) (*http.Request, error) {
+	if req.GetBody == nil {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	r := req.Clone(req.Context())
+	r.Body = body
+	return r, nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
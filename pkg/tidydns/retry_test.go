@@ -0,0 +1,175 @@
+package tidydns
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testRetryPolicy(events *[]RetryEvent) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  time.Millisecond,
+		MaxBackoff:  5 * time.Millisecond,
+		OnRetry: func(e RetryEvent) {
+			*events = append(*events, e)
+		},
+	}
+}
+
+func TestRetryTransientFailure(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if calls.Add(1) < 3 {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = rw.Write([]byte(freeIPResponse))
+	}))
+	defer server.Close()
+
+	var events []RetryEvent
+	c, err := NewWithOptions(server.URL, "username", "password", WithRetryPolicy(testRetryPolicy(&events)))
+	assert.NoError(t, err)
+	ip, err := c.GetFreeIP(context.Background(), 1185)
+	assert.NoError(t, err)
+	assert.Equal(t, "10.68.0.134", ip)
+	assert.Equal(t, int32(3), calls.Load())
+
+	assert.Len(t, events, 2)
+	assert.Equal(t, "GET", events[0].Method)
+	assert.Equal(t, "/=/dhcp_subnet_free_ip/1185", events[0].Path)
+	assert.Equal(t, 1, events[0].Attempt)
+	assert.Equal(t, http.StatusServiceUnavailable, events[0].StatusCode)
+	assert.Equal(t, 2, events[1].Attempt)
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		calls.Add(1)
+		rw.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	var events []RetryEvent
+	c, err := NewWithOptions(server.URL, "username", "password", WithRetryPolicy(testRetryPolicy(&events)))
+	assert.NoError(t, err)
+	err = c.DeleteRecord(context.Background(), 2861, 64694)
+	assert.Error(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Len(t, events, 2)
+}
+
+func TestRetrySkipsPermanentFailure(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		calls.Add(1)
+		rw.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	var events []RetryEvent
+	c, err := NewWithOptions(server.URL, "username", "password", WithRetryPolicy(testRetryPolicy(&events)))
+	assert.NoError(t, err)
+	_, err = c.ReadRecord(context.Background(), 2861, 64694)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Empty(t, events)
+}
+
+func TestRetryPOST(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.NoError(t, req.ParseForm())
+		assert.Equal(t, "test-tal-update", req.PostForm.Get("name"))
+		if calls.Add(1) == 1 {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = rw.Write([]byte(createResponseV1))
+	}))
+	defer server.Close()
+
+	var events []RetryEvent
+	c, err := NewWithOptions(server.URL, "username", "password", WithRetryPolicy(testRetryPolicy(&events)))
+	assert.NoError(t, err)
+	_, err = c.UpdateDHCPInterfaceName(context.Background(), 30641, "test-tal-update")
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+
+	calls.Store(0)
+	policy := testRetryPolicy(&events)
+	policy.RetryNonIdempotent = true
+	c, err = NewWithOptions(server.URL, "username", "password", WithRetryPolicy(policy))
+	assert.NoError(t, err)
+	id, err := c.UpdateDHCPInterfaceName(context.Background(), 30641, "test-tal-update")
+	assert.NoError(t, err)
+	assert.Equal(t, 30641, id)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if calls.Add(1) == 1 {
+			rw.Header().Set("Retry-After", "1")
+			rw.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = rw.Write([]byte(freeIPResponse))
+	}))
+	defer server.Close()
+
+	var events []RetryEvent
+	c, err := NewWithOptions(server.URL, "username", "password", WithRetryPolicy(testRetryPolicy(&events)))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = c.GetFreeIP(ctx, 1185)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Len(t, events, 1)
+	assert.Equal(t, time.Second, events[0].Delay)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "http://tidydns.test/=/zone/", nil)
+	err := &APIError{StatusCode: http.StatusServiceUnavailable}
+
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		max     time.Duration
+	}{
+		{"first", RetryPolicy{MaxAttempts: 10, MinBackoff: time.Millisecond, MaxBackoff: time.Second}, 1, time.Millisecond},
+		{"doubles", RetryPolicy{MaxAttempts: 10, MinBackoff: time.Millisecond, MaxBackoff: time.Second}, 4, 8 * time.Millisecond},
+		{"capped", RetryPolicy{MaxAttempts: 10, MinBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}, 4, 5 * time.Millisecond},
+		{"zero minimum", RetryPolicy{MaxAttempts: 10, MaxBackoff: time.Minute}, 1, defaultMinBackoff},
+		{"zero minimum doubles", RetryPolicy{MaxAttempts: 10, MaxBackoff: time.Minute}, 2, 2 * defaultMinBackoff},
+		{"zero minimum capped", RetryPolicy{MaxAttempts: 10, MaxBackoff: 50 * time.Millisecond}, 1, 50 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, ok := tt.policy.backoff(req, tt.attempt, err)
+			assert.True(t, ok)
+			assert.GreaterOrEqual(t, delay, tt.max/2)
+			assert.LessOrEqual(t, delay, tt.max)
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	assert.Equal(t, 3*time.Second, parseRetryAfter("3"))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(""))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon"))
+
+	d := parseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	assert.Greater(t, d, 50*time.Second)
+}
@@ -0,0 +1,223 @@
+package tidydns
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+const (
+	reverseZoneIPv4 = "in-addr.arpa"
+	reverseZoneIPv6 = "ip6.arpa"
+)
+
+// WithPTRRecordType sets the number TidyDNS uses for PTR records. It is
+// required by EnsurePTR, as the number is not known by this package, but can
+// be read from the type of an existing PTR record.
+func WithPTRRecordType(t RecordType) Option {
+	return func(c *config) {
+		c.ptrRecordType = &t
+	}
+}
+
+// ReverseName returns the name of the PTR record of an address, e.g.
+// "2.1.68.10.in-addr.arpa" for 10.68.1.2.
+func ReverseName(addr netip.Addr) string {
+	addr = addr.Unmap()
+	var labels []string
+	if addr.Is4() {
+		b := addr.As4()
+		for i := len(b) - 1; i >= 0; i-- {
+			labels = append(labels, strconv.Itoa(int(b[i])))
+		}
+		return strings.Join(append(labels, reverseZoneIPv4), ".")
+	}
+	b := addr.As16()
+	for i := len(b) - 1; i >= 0; i-- {
+		labels = append(labels, strconv.FormatUint(uint64(b[i]&0xf), 16), strconv.FormatUint(uint64(b[i]>>4), 16))
+	}
+	return strings.Join(append(labels, reverseZoneIPv6), ".")
+}
+
+// ReversePrefix returns the network covered by a reverse zone. It is taken
+// from the network or address range configured on the zone and otherwise
+// derived from the zone name. It returns false for forward zones.
+func (z *Zone) ReversePrefix() (netip.Prefix, bool) {
+	if p, err := netip.ParsePrefix(z.Network); err == nil {
+		return p.Masked(), true
+	}
+	start, errStart := netip.ParseAddr(z.RangeStart)
+	stop, errStop := netip.ParseAddr(z.RangeStop)
+	if errStart == nil && errStop == nil && start.BitLen() == stop.BitLen() {
+		return commonPrefix(start, stop), true
+	}
+	return reverseNamePrefix(z.Name)
+}
+
+// PTRName returns the name, relative to the zone, of the PTR record of an
+// address in a reverse zone.
+func (z *Zone) PTRName(addr netip.Addr) (string, error) {
+	addr = addr.Unmap()
+	prefix, ok := z.ReversePrefix()
+	if !ok || !prefix.Contains(addr) {
+		return "", fmt.Errorf("%w: zone %s does not cover %s", ErrInvalidArgument, z.Name, addr)
+	}
+
+	name := ReverseName(addr)
+	if relative, ok := strings.CutSuffix(name, "."+strings.TrimSuffix(z.Name, ".")); ok {
+		return relative, nil
+	}
+	// Classless delegations (RFC 2317) are named after the network, so the
+	// record is named by the labels of the host part of the address.
+	labelBits := 8
+	if addr.Is6() {
+		labelBits = 4
+	}
+	n := (addr.BitLen() - prefix.Bits() + labelBits - 1) / labelBits
+	return strings.Join(strings.Split(name, ".")[:n], "."), nil
+}
+
+// reverseNamePrefix derives the network of a reverse zone from its name,
+// e.g. 10.68.1.0/24 from "1.68.10.in-addr.arpa".
+func reverseNamePrefix(name string) (netip.Prefix, bool) {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	if labels, ok := strings.CutSuffix(name, "."+reverseZoneIPv4); ok {
+		parts := strings.Split(labels, ".")
+		if len(parts) > 4 {
+			return netip.Prefix{}, false
+		}
+		var b [4]byte
+		for i, part := range parts {
+			n, err := strconv.ParseUint(part, 10, 8)
+			if err != nil {
+				return netip.Prefix{}, false
+			}
+			b[len(parts)-1-i] = byte(n)
+		}
+		return netip.PrefixFrom(netip.AddrFrom4(b), 8*len(parts)), true
+	}
+	if labels, ok := strings.CutSuffix(name, "."+reverseZoneIPv6); ok {
+		parts := strings.Split(labels, ".")
+		if len(parts) > 32 {
+			return netip.Prefix{}, false
+		}
+		var b [16]byte
+		for i, part := range parts {
+			n, err := strconv.ParseUint(part, 16, 4)
+			if err != nil || len(part) != 1 {
+				return netip.Prefix{}, false
+			}
+			nibble := len(parts) - 1 - i
+			b[nibble/2] |= byte(n) << (4 * (1 - nibble%2))
+		}
+		return netip.PrefixFrom(netip.AddrFrom16(b), 4*len(parts)), true
+	}
+	return netip.Prefix{}, false
+}
+
+// commonPrefix returns the longest prefix containing both addresses.
+func commonPrefix(a, b netip.Addr) netip.Prefix {
+	bits := 0
+	for bits < a.BitLen() {
+		p, _ := a.Prefix(bits + 1)
+		if !p.Contains(b) {
+			break
+		}
+		bits++
+	}
+	p, _ := a.Prefix(bits)
+	return p
+}
+
+// bestReverseZone returns the reverse zone covering addr with the longest
+// prefix, or nil if no zone covers it.
+func bestReverseZone(zones []*Zone, addr netip.Addr) *Zone {
+	addr = addr.Unmap()
+	var best *Zone
+	bestBits := -1
+	for _, z := range zones {
+		prefix, ok := z.ReversePrefix()
+		if !ok || !prefix.Contains(addr) {
+			continue
+		}
+		if prefix.Bits() > bestBits {
+			best, bestBits = z, prefix.Bits()
+		}
+	}
+	return best
+}
+
+func (c *tidyDNSClient) FindReverseZoneForIP(ctx context.Context, addr netip.Addr) (_ *Zone, err error) {
+	ctx, end := c.startOperation(ctx, "FindReverseZoneForIP", attrAddress.String(addr.String()))
+	defer func() { end(err) }()
+
+	if !addr.IsValid() {
+		return nil, fmt.Errorf("%w: invalid address", ErrInvalidArgument)
+	}
+
+	zones, err := c.ListZonesDetailed(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	zone := bestReverseZone(zones, addr)
+	if zone == nil {
+		return nil, fmt.Errorf("%w: no reverse zone for %s", ErrNotFound, addr)
+	}
+	return zone, nil
+}
+
+func (c *tidyDNSClient) EnsurePTR(ctx context.Context, addr netip.Addr, fqdn string) (_ int, err error) {
+	ctx, end := c.startOperation(ctx, "EnsurePTR", attrAddress.String(addr.String()))
+	defer func() { end(err) }()
+
+	if c.ptrRecordType == nil {
+		return 0, fmt.Errorf("%w: EnsurePTR requires WithPTRRecordType", ErrInvalidArgument)
+	}
+	if err := validateHostname("PTR destination", fqdn); err != nil {
+		return 0, err
+	}
+	fqdn = strings.TrimSuffix(fqdn, ".") + "."
+
+	zone, err := c.FindReverseZoneForIP(ctx, addr)
+	if err != nil {
+		return 0, err
+	}
+	name, err := zone.PTRName(addr)
+	if err != nil {
+		return 0, err
+	}
+
+	records, err := c.FindRecord(ctx, zone.ID, name, *c.ptrRecordType)
+	if err != nil {
+		return 0, err
+	}
+	if len(records) == 0 {
+		return c.CreateRecord(ctx, zone.ID, RecordInfo{
+			Type:        *c.ptrRecordType,
+			Name:        name,
+			Destination: fqdn,
+		})
+	}
+	// Several PTR records make reverse lookups ambiguous, but which one is
+	// right is for the caller to decide.
+	if len(records) > 1 {
+		ids := make([]string, len(records))
+		for i, r := range records {
+			ids[i] = strconv.Itoa(r.ID)
+		}
+		return 0, fmt.Errorf("%w: %d PTR records for %s in zone %d: %s", ErrConflict, len(records), addr, zone.ID, strings.Join(ids, ", "))
+	}
+
+	record := records[0]
+	// Host names are case-insensitive, so only a different name is an update.
+	if !strings.EqualFold(strings.TrimSuffix(record.Destination, ".")+".", fqdn) {
+		record.Destination = fqdn
+		if err := c.UpdateRecord(ctx, zone.ID, record.ID, *record); err != nil {
+			return 0, err
+		}
+	}
+	return record.ID, nil
+}
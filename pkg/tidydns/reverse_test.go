@@ -0,0 +1,206 @@
+package tidydns
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReverseName(t *testing.T) {
+	tests := map[string]string{
+		"10.68.1.2":          "2.1.68.10.in-addr.arpa",
+		"::ffff:10.68.1.2":   "2.1.68.10.in-addr.arpa",
+		"2001:db8::567:89ab": "b.a.9.8.7.6.5.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, ReverseName(netip.MustParseAddr(in)), in)
+	}
+}
+
+func TestReversePrefix(t *testing.T) {
+	tests := []struct {
+		zone Zone
+		want string
+	}{
+		{Zone{Name: "1.68.10.in-addr.arpa"}, "10.68.1.0/24"},
+		{Zone{Name: "10.in-addr.arpa."}, "10.0.0.0/8"},
+		{Zone{Name: "8.b.d.0.1.0.0.2.ip6.arpa"}, "2001:db8::/32"},
+		{Zone{Name: "0-25.1.68.10.in-addr.arpa", Network: "10.68.1.0/25"}, "10.68.1.0/25"},
+		{Zone{Name: "0-25.1.68.10.in-addr.arpa", RangeStart: "10.68.1.0", RangeStop: "10.68.1.127"}, "10.68.1.0/25"},
+	}
+	for _, test := range tests {
+		prefix, ok := test.zone.ReversePrefix()
+		assert.True(t, ok, test.zone.Name)
+		assert.Equal(t, test.want, prefix.String(), test.zone.Name)
+	}
+
+	for _, name := range []string{"netic.dk", "in-addr.arpa", "300.in-addr.arpa", "1.2.3.4.5.in-addr.arpa", "12.ip6.arpa"} {
+		_, ok := (&Zone{Name: name}).ReversePrefix()
+		assert.False(t, ok, name)
+	}
+}
+
+func TestPTRName(t *testing.T) {
+	zone := &Zone{Name: "68.10.in-addr.arpa"}
+	name, err := zone.PTRName(netip.MustParseAddr("10.68.1.2"))
+	assert.NoError(t, err)
+	assert.Equal(t, "2.1", name)
+
+	classless := &Zone{Name: "0-25.1.68.10.in-addr.arpa", Network: "10.68.1.0/25"}
+	name, err = classless.PTRName(netip.MustParseAddr("10.68.1.2"))
+	assert.NoError(t, err)
+	assert.Equal(t, "2", name)
+
+	_, err = zone.PTRName(netip.MustParseAddr("10.69.1.2"))
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+}
+
+func TestFindReverseZoneForIP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/=/zone", req.URL.Path)
+		_, _ = rw.Write([]byte(listReverseZonesResponse))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	zone, err := c.FindReverseZoneForIP(context.Background(), netip.MustParseAddr("10.68.1.2"))
+	assert.NoError(t, err)
+	assert.Equal(t, 12, zone.ID)
+	assert.Equal(t, "10.68.1.0/25", zone.Network)
+	assert.Equal(t, "classless", zone.ReverseClass)
+
+	zone, err = c.FindReverseZoneForIP(context.Background(), netip.MustParseAddr("10.68.1.200"))
+	assert.NoError(t, err)
+	assert.Equal(t, 11, zone.ID)
+
+	zone, err = c.FindReverseZoneForIP(context.Background(), netip.MustParseAddr("2001:db8::1"))
+	assert.NoError(t, err)
+	assert.Equal(t, 13, zone.ID)
+
+	_, err = c.FindReverseZoneForIP(context.Background(), netip.MustParseAddr("192.0.2.1"))
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = c.FindReverseZoneForIP(context.Background(), netip.Addr{})
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+}
+
+func TestEnsurePTR(t *testing.T) {
+	var updated int
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.Method == "GET" && req.URL.Path == "/=/zone":
+			_, _ = rw.Write([]byte(listReverseZonesResponse))
+		case req.Method == "GET" && req.URL.Path == "/=/record":
+			assert.Equal(t, "12", req.URL.Query().Get("zone"))
+			assert.Equal(t, "2", req.URL.Query().Get("name"))
+			_, _ = rw.Write([]byte(`[
+				{"id": 500, "type": 5, "type_name": "TXT", "name": "2", "destination": "old.netic.dk.", "status": "0"},
+				{"id": 501, "type": 100, "type_name": "PTR", "name": "2", "destination": "old.netic.dk.", "status": "0"}
+			]`))
+		case req.Method == "POST" && req.URL.Path == "/=/record/501/12":
+			assert.NoError(t, req.ParseForm())
+			assert.Equal(t, "node1.netic.dk.", req.PostForm.Get("destination"))
+			updated++
+			_, _ = rw.Write([]byte(`{"status":"0"}`))
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewWithOptions(server.URL, "username", "password", WithPTRRecordType(100))
+	assert.NoError(t, err)
+	id, err := c.EnsurePTR(context.Background(), netip.MustParseAddr("10.68.1.2"), "node1.netic.dk")
+	assert.NoError(t, err)
+	assert.Equal(t, 501, id)
+	assert.Equal(t, 1, updated)
+
+	_, err = c.EnsurePTR(context.Background(), netip.MustParseAddr("10.68.1.2"), "not a host")
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+}
+
+func TestEnsurePTRConflict(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.Method == "GET" && req.URL.Path == "/=/zone":
+			_, _ = rw.Write([]byte(listReverseZonesResponse))
+		case req.Method == "GET" && req.URL.Path == "/=/record":
+			_, _ = rw.Write([]byte(`[
+				{"id": 501, "type": 100, "type_name": "PTR", "name": "2", "destination": "old.netic.dk.", "status": "0"},
+				{"id": 502, "type": 100, "type_name": "PTR", "name": "2", "destination": "other.netic.dk.", "status": "0"}
+			]`))
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewWithOptions(server.URL, "username", "password", WithPTRRecordType(100))
+	assert.NoError(t, err)
+	_, err = c.EnsurePTR(context.Background(), netip.MustParseAddr("10.68.1.2"), "node1.netic.dk")
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.ErrorContains(t, err, "501, 502")
+}
+
+func TestEnsurePTRUnchanged(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.Method == "GET" && req.URL.Path == "/=/zone":
+			_, _ = rw.Write([]byte(listReverseZonesResponse))
+		case req.Method == "GET" && req.URL.Path == "/=/record":
+			_, _ = rw.Write([]byte(`[{"id": 501, "type": 100, "type_name": "PTR", "name": "2", "destination": "Node1.Netic.DK", "status": "0"}]`))
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewWithOptions(server.URL, "username", "password", WithPTRRecordType(100))
+	assert.NoError(t, err)
+	id, err := c.EnsurePTR(context.Background(), netip.MustParseAddr("10.68.1.2"), "node1.netic.dk.")
+	assert.NoError(t, err)
+	assert.Equal(t, 501, id)
+}
+
+func TestEnsurePTRCreate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.Method == "GET" && req.URL.Path == "/=/zone":
+			_, _ = rw.Write([]byte(listReverseZonesResponse))
+		case req.Method == "GET" && req.URL.Path == "/=/record":
+			_, _ = rw.Write([]byte(`[]`))
+		case req.Method == "POST" && req.URL.Path == "/=/record/new/13":
+			assert.NoError(t, req.ParseForm())
+			assert.Equal(t, "100", req.PostForm.Get("type"))
+			assert.Equal(t, "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0", req.PostForm.Get("name"))
+			assert.Equal(t, "node1.netic.dk.", req.PostForm.Get("destination"))
+			_, _ = rw.Write([]byte(`{"status":"0"}`))
+		case req.Method == "GET" && req.URL.Path == "/=/record_merged":
+			_, _ = rw.Write([]byte(`[{"id": 601, "type": 100, "type_name": "PTR", "name": "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0", "destination": "node1.netic.dk.", "status": "0"}]`))
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	_, err := c.EnsurePTR(context.Background(), netip.MustParseAddr("2001:db8::1"), "node1.netic.dk.")
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+
+	c, err = NewWithOptions(server.URL, "username", "password", WithPTRRecordType(100))
+	assert.NoError(t, err)
+	id, err := c.EnsurePTR(context.Background(), netip.MustParseAddr("2001:db8::1"), "node1.netic.dk.")
+	assert.NoError(t, err)
+	assert.Equal(t, 601, id)
+}
+
+const listReverseZonesResponse = `[
+  {"id": 10, "name": "netic.dk", "type": 0},
+  {"id": 11, "name": "1.68.10.in-addr.arpa", "type": 0, "reverse_network": null, "reverse_class": "in-addr"},
+  {"id": 12, "name": "0-25.1.68.10.in-addr.arpa", "type": 0, "reverse_network": "10.68.1.0/25", "reverse_range_start": "10.68.1.0", "reverse_range_stop": "10.68.1.127", "reverse_class": "classless"},
+  {"id": 13, "name": "8.b.d.0.1.0.0.2.ip6.arpa", "type": 0, "reverse_class": "ip6"}
+]`
@@ -0,0 +1,457 @@
+package tidydns
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Subnet is the configuration of a DHCP subnet.
+type Subnet struct {
+	ID          int
+	Name        string
+	Description string
+	// Prefix is the network of the subnet, e.g. 10.68.0.128/26.
+	Prefix netip.Prefix
+	// Family is the IP version of the subnet, 4 or 6.
+	Family int
+	// VlanID is the TidyDNS ID of the VLAN, VlanNo its VLAN tag.
+	VlanID   int
+	VlanNo   int
+	VlanName string
+	// ZoneID and ZoneName identify the zone records of the subnet are
+	// created in.
+	ZoneID       int
+	ZoneName     string
+	LocationID   LocationID
+	LocationName string
+	CustomerID   int
+	DHCPActive   bool
+	DHCPFailover bool
+	// VMPSActive enables VLAN membership policy for the subnet.
+	VMPSActive bool
+	// SharedNetwork is the DHCP shared network the subnet is part of, if
+	// any.
+	SharedNetwork string
+	Status        int
+	CreatedDate   time.Time
+	ModifiedDate  time.Time
+	ModifiedBy    string
+}
+
+// SubnetFilter selects the subnets returned by ListSubnets. Zero values
+// match all subnets.
+type SubnetFilter struct {
+	VlanNo int
+	// LocationID matches subnets at the given location if not nil.
+	LocationID *LocationID
+	// Family is 4 or 6 to match subnets of one IP version.
+	Family int
+	// CustomerID matches subnets of the given customer if not nil.
+	CustomerID *int
+}
+
+// Matches reports whether a subnet is selected by the filter.
+func (f SubnetFilter) Matches(s *Subnet) bool {
+	if f.VlanNo != 0 && s.VlanNo != f.VlanNo {
+		return false
+	}
+	if f.LocationID != nil && s.LocationID != *f.LocationID {
+		return false
+	}
+	if f.Family != 0 && s.Family != f.Family {
+		return false
+	}
+	if f.CustomerID != nil && s.CustomerID != *f.CustomerID {
+		return false
+	}
+	return true
+}
+
+// SubnetSettings are the settings of a subnet that can be changed through
+// the API. Nil fields are left unchanged by UpdateSubnet and get the
+// defaults of the TidyDNS installation on CreateSubnet. The network of a
+// subnet is given on CreateSubnet and cannot be changed afterwards; to
+// renumber a subnet, create a new one and delete the old.
+type SubnetSettings struct {
+	Name        *string
+	Description *string
+	// VlanID is the TidyDNS ID of the VLAN, not the VLAN tag.
+	VlanID       *int
+	ZoneID       *int
+	LocationID   *LocationID
+	DHCPActive   *bool
+	DHCPFailover *bool
+	VMPSActive   *bool
+}
+
+// Validate returns an error matching ErrInvalidArgument if the settings
+// cannot be stored in TidyDNS.
+func (s SubnetSettings) Validate() error {
+	if s.Name != nil && *s.Name == "" {
+		return fmt.Errorf("%w: empty subnet name", ErrInvalidArgument)
+	}
+	ids := map[string]*int{"VLAN": s.VlanID, "zone": s.ZoneID}
+	for name, id := range ids {
+		if id != nil && *id <= 0 {
+			return fmt.Errorf("%w: invalid %s ID %d", ErrInvalidArgument, name, *id)
+		}
+	}
+	if s.LocationID != nil && *s.LocationID < 0 {
+		return fmt.Errorf("%w: invalid location ID %d", ErrInvalidArgument, *s.LocationID)
+	}
+	return nil
+}
+
+// form adds the settings to a create or update request.
+func (s SubnetSettings) form(data url.Values) {
+	if s.Name != nil {
+		data.Set("name", *s.Name)
+	}
+	if s.Description != nil {
+		data.Set("description", *s.Description)
+	}
+	ids := map[string]*int{"vlan_id": s.VlanID, "zone_id": s.ZoneID}
+	for key, id := range ids {
+		if id != nil {
+			data.Set(key, strconv.Itoa(*id))
+		}
+	}
+	if s.LocationID != nil {
+		data.Set("location_id", strconv.Itoa(int(*s.LocationID)))
+	}
+	flags := map[string]*bool{
+		"dhcp_active":   s.DHCPActive,
+		"dhcp_failover": s.DHCPFailover,
+		"vmps_active":   s.VMPSActive,
+	}
+	for key, b := range flags {
+		if b != nil {
+			data.Set(key, formBool(*b))
+		}
+	}
+}
+
+// SubnetOverlapError is returned by CreateSubnet when the network of a new
+// subnet overlaps an existing subnet. It matches ErrConflict, and
+// ErrAlreadyExists if the networks are equal.
+type SubnetOverlapError struct {
+	Prefix netip.Prefix
+	// Subnet is the existing subnet.
+	Subnet *Subnet
+}
+
+func (e *SubnetOverlapError) Error() string {
+	return fmt.Sprintf("subnet %s overlaps subnet %d (%s)", e.Prefix, e.Subnet.ID, e.Subnet.Prefix)
+}
+
+// Is reports whether target is ErrConflict, or ErrAlreadyExists for equal
+// networks.
+func (e *SubnetOverlapError) Is(target error) bool {
+	switch target {
+	case ErrConflict:
+		return true
+	case ErrAlreadyExists:
+		return e.Prefix == e.Subnet.Prefix.Masked()
+	}
+	return false
+}
+
+// CheckSubnetOverlap returns a *SubnetOverlapError if prefix overlaps one
+// of the subnets.
+func CheckSubnetOverlap(prefix netip.Prefix, subnets []*Subnet) error {
+	prefix = prefix.Masked()
+	for _, s := range subnets {
+		if s.Prefix.Overlaps(prefix) {
+			return &SubnetOverlapError{Prefix: prefix, Subnet: s}
+		}
+	}
+	return nil
+}
+
+type subnetCreate struct {
+	ID   int `json:"id"`
+	Data struct {
+		ID int `json:"id"`
+	} `json:"data"`
+}
+
+type subnetRead struct {
+	ID            int        `json:"id"`
+	Name          string     `json:"name"`
+	Description   string     `json:"description"`
+	Subnet        string     `json:"subnet"`
+	Family        int        `json:"family"`
+	VlanID        int        `json:"vlan_id"`
+	VlanNo        int        `json:"vlan_no"`
+	VlanName      string     `json:"vlan_name"`
+	ZoneID        int        `json:"zone_id"`
+	Zone          string     `json:"zone"`
+	LocationID    LocationID `json:"location_id"`
+	LocName       string     `json:"loc_name"`
+	CustomerID    int        `json:"customer_id"`
+	DHCPActive    int        `json:"dhcp_active"`
+	DHCPFailover  int        `json:"dhcp_failover"`
+	VMPSActive    int        `json:"vmps_active"`
+	SharedNetwork string     `json:"shared_network"`
+	Status        int        `json:"status"`
+	CreatedDate   string     `json:"created_date"`
+	ModifiedDate  string     `json:"modified_date"`
+	ModifiedBy    string     `json:"modified_by"`
+}
+
+func (s subnetRead) subnet() (*Subnet, error) {
+	prefix, err := netip.ParsePrefix(s.Subnet)
+	if err != nil {
+		return nil, fmt.Errorf("subnet %d: %w", s.ID, err)
+	}
+	subnet := &Subnet{
+		ID:            s.ID,
+		Name:          s.Name,
+		Description:   s.Description,
+		Prefix:        prefix,
+		Family:        s.Family,
+		VlanID:        s.VlanID,
+		VlanNo:        s.VlanNo,
+		VlanName:      s.VlanName,
+		ZoneID:        s.ZoneID,
+		ZoneName:      s.Zone,
+		LocationID:    s.LocationID,
+		LocationName:  s.LocName,
+		CustomerID:    s.CustomerID,
+		DHCPActive:    s.DHCPActive != 0,
+		DHCPFailover:  s.DHCPFailover != 0,
+		VMPSActive:    s.VMPSActive != 0,
+		SharedNetwork: s.SharedNetwork,
+		Status:        s.Status,
+		ModifiedBy:    s.ModifiedBy,
+	}
+	if subnet.Family == 0 {
+		subnet.Family = 6
+		if prefix.Addr().Is4() {
+			subnet.Family = 4
+		}
+	}
+
+	dates := []struct {
+		value string
+		dst   *time.Time
+	}{
+		{s.CreatedDate, &subnet.CreatedDate},
+		{s.ModifiedDate, &subnet.ModifiedDate},
+	}
+	for _, d := range dates {
+		if d.value == "" {
+			continue
+		}
+		t, err := time.Parse(time.DateTime, d.value)
+		if err != nil {
+			return nil, fmt.Errorf("subnet %d: %w", s.ID, err)
+		}
+		*d.dst = t
+	}
+	return subnet, nil
+}
+
+// bestSubnet returns the subnet containing addr with the longest prefix, or
+// nil if no subnet contains it.
+func bestSubnet(subnets []*Subnet, addr netip.Addr) *Subnet {
+	addr = addr.Unmap()
+	var best *Subnet
+	for _, s := range subnets {
+		if !s.Prefix.Contains(addr) {
+			continue
+		}
+		if best == nil || s.Prefix.Bits() > best.Prefix.Bits() {
+			best = s
+		}
+	}
+	return best
+}
+
+func (c *tidyDNSClient) ListSubnets(ctx context.Context, filter SubnetFilter) (_ []*Subnet, err error) {
+	ctx, end := c.startOperation(ctx, "ListSubnets")
+	defer func() { end(err) }()
+
+	var subnets []subnetRead
+	dhcpSubnetUrl := fmt.Sprintf("%s/=/dhcp_subnet?type=json", c.baseURL)
+	err = c.getData(
+		ctx,
+		dhcpSubnetUrl,
+		&subnets,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Subnet, 0, len(subnets))
+	for _, s := range subnets {
+		subnet, err := s.subnet()
+		if err != nil {
+			return nil, err
+		}
+		if filter.Matches(subnet) {
+			result = append(result, subnet)
+		}
+	}
+	return result, nil
+}
+
+func (c *tidyDNSClient) GetSubnet(ctx context.Context, subnetID int) (_ *Subnet, err error) {
+	ctx, end := c.startOperation(ctx, "GetSubnet", attrSubnetID.Int(subnetID))
+	defer func() { end(err) }()
+
+	var subnet subnetRead
+	dhcpSubnetUrl := fmt.Sprintf("%s/=/dhcp_subnet/%d?type=json", c.baseURL, subnetID)
+	err = c.getData(
+		ctx,
+		dhcpSubnetUrl,
+		&subnet,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return subnet.subnet()
+}
+
+func (c *tidyDNSClient) GetSubnetByPrefix(ctx context.Context, prefix netip.Prefix) (_ *Subnet, err error) {
+	ctx, end := c.startOperation(ctx, "GetSubnetByPrefix", attrSubnet.String(prefix.String()))
+	defer func() { end(err) }()
+
+	if !prefix.IsValid() {
+		return nil, fmt.Errorf("%w: invalid prefix", ErrInvalidArgument)
+	}
+	prefix = prefix.Masked()
+
+	var subnets []subnetRead
+	dhcpSubnetUrl := fmt.Sprintf("%s/=/dhcp_subnet?type=json&subnet=%s", c.baseURL, url.QueryEscape(prefix.String()))
+	err = c.getData(
+		ctx,
+		dhcpSubnetUrl,
+		&subnets,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range subnets {
+		subnet, err := s.subnet()
+		if err != nil {
+			return nil, err
+		}
+		// Compare parsed prefixes, as TidyDNS may format IPv6 networks
+		// differently.
+		if subnet.Prefix.Masked() == prefix {
+			return subnet, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: subnet %s", ErrNotFound, prefix)
+}
+
+func (c *tidyDNSClient) FindSubnetForIP(ctx context.Context, addr netip.Addr) (_ *Subnet, err error) {
+	ctx, end := c.startOperation(ctx, "FindSubnetForIP", attrAddress.String(addr.String()))
+	defer func() { end(err) }()
+
+	if !addr.IsValid() {
+		return nil, fmt.Errorf("%w: invalid address", ErrInvalidArgument)
+	}
+
+	subnets, err := c.ListSubnets(ctx, SubnetFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	subnet := bestSubnet(subnets, addr)
+	if subnet == nil {
+		return nil, fmt.Errorf("%w: no subnet for %s", ErrNotFound, addr)
+	}
+	return subnet, nil
+}
+
+func (c *tidyDNSClient) CreateSubnet(ctx context.Context, prefix netip.Prefix, settings SubnetSettings) (_ int, err error) {
+	ctx, end := c.startOperation(ctx, "CreateSubnet", attrSubnet.String(prefix.String()))
+	defer func() { end(err) }()
+
+	if !prefix.IsValid() || prefix != prefix.Masked() {
+		return 0, fmt.Errorf("%w: invalid subnet %s", ErrInvalidArgument, prefix)
+	}
+	if err := settings.Validate(); err != nil {
+		return 0, err
+	}
+
+	subnets, err := c.ListSubnets(ctx, SubnetFilter{})
+	if err != nil {
+		return 0, err
+	}
+	if err := CheckSubnetOverlap(prefix, subnets); err != nil {
+		return 0, err
+	}
+
+	data := url.Values{
+		"subnet": {prefix.String()},
+	}
+	settings.form(data)
+
+	var subnet subnetCreate
+	newSubnetUrl := fmt.Sprintf("%s/=/dhcp_subnet/new", c.baseURL)
+	err = c.postForm(ctx, newSubnetUrl, data, &subnet)
+	if err != nil {
+		return 0, err
+	}
+
+	if subnet.ID != 0 {
+		return subnet.ID, nil
+	}
+	if subnet.Data.ID != 0 {
+		return subnet.Data.ID, nil
+	}
+	created, err := c.GetSubnetByPrefix(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+	return created.ID, nil
+}
+
+func (c *tidyDNSClient) UpdateSubnet(ctx context.Context, subnetID int, settings SubnetSettings) (err error) {
+	ctx, end := c.startOperation(ctx, "UpdateSubnet", attrSubnetID.Int(subnetID))
+	defer func() { end(err) }()
+
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	data := url.Values{}
+	settings.form(data)
+
+	dhcpSubnetUrl := fmt.Sprintf("%s/=/dhcp_subnet/%d", c.baseURL, subnetID)
+	return c.postForm(ctx, dhcpSubnetUrl, data, nil)
+}
+
+func (c *tidyDNSClient) DeleteSubnet(ctx context.Context, subnetID int) (err error) {
+	ctx, end := c.startOperation(ctx, "DeleteSubnet", attrSubnetID.Int(subnetID))
+	defer func() { end(err) }()
+
+	dhcpSubnetUrl := fmt.Sprintf("%s/=/dhcp_subnet/%d", c.baseURL, subnetID)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"DELETE",
+		dhcpSubnetUrl,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer closeResponse(res)
+
+	return nil
+}
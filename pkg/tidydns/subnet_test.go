@@ -0,0 +1,240 @@
+package tidydns
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const subnetListResponse = `[
+  {"id": 1185, "subnet": "10.68.0.128/26", "family": 4, "vlan_no": 534, "location_id": 1, "customer_id": 0},
+  {"id": 1186, "subnet": "2a01:4d0:1::/64", "family": 6, "vlan_no": 534, "location_id": 1, "customer_id": 0},
+  {"id": 1187, "subnet": "10.68.1.0/24", "family": 4, "vlan_no": 535, "location_id": 2, "customer_id": 42}
+]`
+
+func TestGetSubnet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "GET", req.Method)
+		assert.Equal(t, "/=/dhcp_subnet/1185", req.URL.Path)
+		// The subnet is returned as an object rather than a list.
+		_, _ = rw.Write([]byte(subnetResponse[1 : len(subnetResponse)-1]))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	subnet, err := c.GetSubnet(context.Background(), 1185)
+	assert.NoError(t, err)
+	assert.Equal(t, &Subnet{
+		ID:           1185,
+		Name:         "netic-shared-k8s-utility01-v4",
+		Prefix:       netip.MustParsePrefix("10.68.0.128/26"),
+		Family:       4,
+		VlanID:       959,
+		VlanNo:       534,
+		VlanName:     "netic-shared-k8s-utility01",
+		ZoneID:       2861,
+		ZoneName:     "k8s.netic.dk",
+		LocationID:   1,
+		LocationName: "internal",
+		DHCPFailover: true,
+		CreatedDate:  time.Date(2021, 4, 16, 14, 46, 46, 0, time.UTC),
+		ModifiedDate: time.Date(2021, 7, 8, 10, 38, 2, 0, time.UTC),
+		ModifiedBy:   "mef",
+	}, subnet)
+}
+
+func TestGetSubnetNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	_, err := c.GetSubnet(context.Background(), 1)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestListSubnets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/=/dhcp_subnet", req.URL.Path)
+		_, _ = rw.Write([]byte(subnetListResponse))
+	}))
+	defer server.Close()
+
+	ids := func(subnets []*Subnet) []int {
+		result := []int{}
+		for _, s := range subnets {
+			result = append(result, s.ID)
+		}
+		return result
+	}
+
+	c := New(server.URL, "username", "password")
+	location := LocationID(1)
+	customer := 0
+	tests := []struct {
+		filter SubnetFilter
+		want   []int
+	}{
+		{SubnetFilter{}, []int{1185, 1186, 1187}},
+		{SubnetFilter{VlanNo: 534}, []int{1185, 1186}},
+		{SubnetFilter{Family: 4}, []int{1185, 1187}},
+		{SubnetFilter{LocationID: &location, Family: 6}, []int{1186}},
+		{SubnetFilter{CustomerID: &customer}, []int{1185, 1186}},
+		{SubnetFilter{VlanNo: 1}, []int{}},
+	}
+	for _, test := range tests {
+		subnets, err := c.ListSubnets(context.Background(), test.filter)
+		assert.NoError(t, err)
+		assert.Equal(t, test.want, ids(subnets), "%+v", test.filter)
+	}
+}
+
+func TestGetSubnetByPrefix(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/=/dhcp_subnet", req.URL.Path)
+		assert.Equal(t, "2a01:4d0:1::/64", req.URL.Query().Get("subnet"))
+		_, _ = rw.Write([]byte(`[{"id": 1186, "subnet": "2a01:04d0:0001::/64", "family": 6}]`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	subnet, err := c.GetSubnetByPrefix(context.Background(), netip.MustParsePrefix("2a01:4d0:1::1/64"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1186, subnet.ID)
+
+	_, err = c.GetSubnetByPrefix(context.Background(), netip.Prefix{})
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+}
+
+func TestFindSubnetForIP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte(`[
+			{"id": 1, "subnet": "10.68.0.0/16"},
+			{"id": 1185, "subnet": "10.68.0.128/26"},
+			{"id": 1186, "subnet": "2a01:4d0:1::/64"}
+		]`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	tests := map[string]int{
+		"10.68.0.130":        1185,
+		"::ffff:10.68.0.130": 1185,
+		"10.68.1.1":          1,
+		"2a01:4d0:1::42":     1186,
+	}
+	for addr, want := range tests {
+		subnet, err := c.FindSubnetForIP(context.Background(), netip.MustParseAddr(addr))
+		assert.NoError(t, err, addr)
+		if assert.NotNil(t, subnet, addr) {
+			assert.Equal(t, want, subnet.ID, addr)
+		}
+	}
+
+	_, err := c.FindSubnetForIP(context.Background(), netip.MustParseAddr("192.0.2.1"))
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = c.FindSubnetForIP(context.Background(), netip.Addr{})
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+}
+
+func TestSubnetSettingsValidate(t *testing.T) {
+	empty := ""
+	zero := 0
+	location := LocationID(-1)
+	assert.NoError(t, SubnetSettings{}.Validate())
+	assert.ErrorIs(t, SubnetSettings{Name: &empty}.Validate(), ErrInvalidArgument)
+	assert.ErrorIs(t, SubnetSettings{VlanID: &zero}.Validate(), ErrInvalidArgument)
+	assert.ErrorIs(t, SubnetSettings{ZoneID: &zero}.Validate(), ErrInvalidArgument)
+	assert.ErrorIs(t, SubnetSettings{LocationID: &location}.Validate(), ErrInvalidArgument)
+}
+
+func TestCheckSubnetOverlap(t *testing.T) {
+	subnets := []*Subnet{
+		{ID: 1185, Prefix: netip.MustParsePrefix("10.68.0.128/26")},
+		{ID: 1186, Prefix: netip.MustParsePrefix("2a01:4d0:1::/64")},
+	}
+	assert.NoError(t, CheckSubnetOverlap(netip.MustParsePrefix("10.68.0.192/26"), subnets))
+	assert.NoError(t, CheckSubnetOverlap(netip.MustParsePrefix("2a01:4d0:2::/64"), subnets))
+
+	err := CheckSubnetOverlap(netip.MustParsePrefix("10.68.0.0/24"), subnets)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.NotErrorIs(t, err, ErrAlreadyExists)
+	assert.EqualError(t, err, "subnet 10.68.0.0/24 overlaps subnet 1185 (10.68.0.128/26)")
+
+	err = CheckSubnetOverlap(netip.MustParsePrefix("10.68.0.128/26"), subnets)
+	assert.ErrorIs(t, err, ErrAlreadyExists)
+	assert.ErrorIs(t, err, ErrConflict)
+}
+
+func TestCreateSubnet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.Method == "GET" && req.URL.Path == "/=/dhcp_subnet":
+			_, _ = rw.Write([]byte(subnetListResponse))
+		case req.Method == "POST" && req.URL.Path == "/=/dhcp_subnet/new":
+			assert.NoError(t, req.ParseForm())
+			assert.Equal(t, "dhcp_active=1&name=k8s-cluster&subnet=10.68.2.0%2F26&vlan_id=959&zone_id=2861", req.PostForm.Encode())
+			_, _ = rw.Write([]byte(`{"status": 0, "id": 1190}`))
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	name := "k8s-cluster"
+	vlanID, zoneID := 959, 2861
+	active := true
+	id, err := c.CreateSubnet(context.Background(), netip.MustParsePrefix("10.68.2.0/26"), SubnetSettings{
+		Name:       &name,
+		VlanID:     &vlanID,
+		ZoneID:     &zoneID,
+		DHCPActive: &active,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1190, id)
+
+	_, err = c.CreateSubnet(context.Background(), netip.MustParsePrefix("10.68.0.0/23"), SubnetSettings{})
+	assert.ErrorIs(t, err, ErrConflict)
+	_, err = c.CreateSubnet(context.Background(), netip.MustParsePrefix("10.68.2.1/26"), SubnetSettings{})
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+}
+
+func TestUpdateSubnet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "POST", req.Method)
+		assert.Equal(t, "/=/dhcp_subnet/1185", req.URL.Path)
+		assert.NoError(t, req.ParseForm())
+		assert.Equal(t, "dhcp_failover=0&location_id=2&vmps_active=1", req.PostForm.Encode())
+		_, _ = rw.Write([]byte(`{"status": 0}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	location := LocationID(2)
+	failover, vmps := false, true
+	err := c.UpdateSubnet(context.Background(), 1185, SubnetSettings{
+		LocationID:   &location,
+		DHCPFailover: &failover,
+		VMPSActive:   &vmps,
+	})
+	assert.NoError(t, err)
+}
+
+func TestDeleteSubnet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "DELETE", req.Method)
+		assert.Equal(t, "/=/dhcp_subnet/1185", req.URL.Path)
+		_, _ = rw.Write([]byte(`{"status": 0}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	assert.NoError(t, c.DeleteSubnet(context.Background(), 1185))
+}
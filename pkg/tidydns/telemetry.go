@@ -0,0 +1,114 @@
+package tidydns
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// attrKey is the key of an attribute describing a client method call.
+type attrKey string
+
+// Attribute keys passed to an OperationHook.
+const (
+	attrZoneID      = attrKey("tidydns.zone_id")
+	attrRecordID    = attrKey("tidydns.record_id")
+	attrRecordType  = attrKey("tidydns.record_type")
+	attrZoneName    = attrKey("tidydns.zone_name")
+	attrSubnet      = attrKey("tidydns.subnet")
+	attrSubnetID    = attrKey("tidydns.subnet_id")
+	attrInterfaceID = attrKey("tidydns.interface_id")
+	attrUserID      = attrKey("tidydns.user_id")
+	attrAddress     = attrKey("tidydns.address")
+)
+
+func (k attrKey) Int(value int) slog.Attr {
+	return slog.Int(string(k), value)
+}
+
+func (k attrKey) String(value string) slog.Attr {
+	return slog.String(string(k), value)
+}
+
+// OperationHook is implemented by metrics hooks that follow client method
+// calls through their context, e.g. to record them as trace spans. It is
+// called in addition to OperationStarted and OperationFinished.
+type OperationHook interface {
+	MetricsHook
+	// StartOperation is called when a client method is called, with
+	// attributes such as the zone ID. The returned context is used for the
+	// HTTP requests of the method, and the returned function is called with
+	// the error the method returned.
+	StartOperation(ctx context.Context, operation string, attrs []slog.Attr) (context.Context, func(err error))
+}
+
+// RequestHook is implemented by metrics hooks that observe every HTTP
+// request sent by client methods, including retries.
+type RequestHook interface {
+	MetricsHook
+	// StartRequest is called before a request is sent. The returned request
+	// is sent instead, and the returned function is called with the status
+	// of the response, or zero if none was received, and the error of the
+	// attempt.
+	StartRequest(req *http.Request, attempt int) (*http.Request, func(status int, err error))
+}
+
+// operationKey marks the context of a client method call, so methods called
+// by other methods, e.g. GetZone by WaitForProvisioned, are not reported as
+// operations of their own.
+type operationKey struct{}
+
+// startOperation reports the start of a client method to the metrics
+// hooks. The returned function must be called with the error returned by
+// the method. Calls made while another method is in progress on the same
+// context are part of that operation and are not reported.
+func (c *tidyDNSClient) startOperation(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, func(error)) {
+	hooks := c.metricsHooks
+	if len(hooks) == 0 || ctx.Value(operationKey{}) != nil {
+		return ctx, func(error) {}
+	}
+	ctx = context.WithValue(ctx, operationKey{}, name)
+
+	start := time.Now()
+	ends := make([]func(error), 0, len(hooks))
+	for _, hook := range hooks {
+		hook.OperationStarted(name)
+		if h, ok := hook.(OperationHook); ok {
+			var end func(error)
+			ctx, end = h.StartOperation(ctx, name, attrs)
+			ends = append(ends, end)
+		}
+	}
+
+	return ctx, func(err error) {
+		duration := time.Since(start)
+		for i := len(ends) - 1; i >= 0; i-- {
+			ends[i](err)
+		}
+		for _, hook := range hooks {
+			hook.OperationFinished(name, duration, err)
+		}
+	}
+}
+
+// startRequest reports a single HTTP request attempt to the metrics hooks.
+func (c *tidyDNSClient) startRequest(req *http.Request, attempt int) (*http.Request, func(status int, err error)) {
+	var ends []func(int, error)
+	for _, hook := range c.metricsHooks {
+		if h, ok := hook.(RequestHook); ok {
+			var end func(int, error)
+			req, end = h.StartRequest(req, attempt)
+			ends = append(ends, end)
+		}
+	}
+	if len(ends) == 0 {
+		return req, func(int, error) {}
+	}
+
+	return req, func(status int, err error) {
+		for i := len(ends) - 1; i >= 0; i-- {
+			ends[i](status, err)
+		}
+	}
+}
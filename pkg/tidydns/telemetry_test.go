@@ -0,0 +1,84 @@
+package tidydns
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ctxKey struct{}
+
+// tracingHook records operations and requests like a tracer, passing the
+// operation name to its requests through the context.
+type tracingHook struct {
+	recordingHook
+	attrs    []slog.Attr
+	requests []string
+	errs     []error
+}
+
+func (h *tracingHook) StartOperation(ctx context.Context, operation string, attrs []slog.Attr) (context.Context, func(error)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.attrs = append(h.attrs, attrs...)
+	return context.WithValue(ctx, ctxKey{}, operation), func(err error) {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.errs = append(h.errs, err)
+	}
+}
+
+func (h *tracingHook) StartRequest(req *http.Request, attempt int) (*http.Request, func(int, error)) {
+	operation, _ := req.Context().Value(ctxKey{}).(string)
+	return req, func(status int, err error) {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.requests = append(h.requests, operation+" "+req.Method+" "+http.StatusText(status))
+	}
+}
+
+func TestOperationHook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method == "GET" && req.URL.Path == "/=/dhcp_subnet_free_ip/404" {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = rw.Write([]byte(readRecordListResponse))
+	}))
+	defer server.Close()
+
+	hook := &tracingHook{}
+	counter := &recordingHook{}
+	c, err := NewWithOptions(server.URL, "username", "password", WithMetricsHook(hook), WithMetricsHook(counter))
+	assert.NoError(t, err)
+
+	_, err = c.CreateRecord(context.Background(), 2861, RecordInfo{
+		Type:        RecordTypeA,
+		Name:        "tal-test",
+		Destination: "10.68.1.2",
+	})
+	assert.NoError(t, err)
+	_, err = c.GetFreeIP(context.Background(), 404)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.Equal(t, []slog.Attr{
+		slog.Int("tidydns.zone_id", 2861),
+		slog.String("tidydns.record_type", "A"),
+		slog.Int("tidydns.subnet_id", 404),
+	}, hook.attrs)
+	assert.Equal(t, []string{
+		"CreateRecord POST OK",
+		"CreateRecord GET OK",
+		"GetFreeIP GET Not Found",
+	}, hook.requests)
+	if assert.Len(t, hook.errs, 2) {
+		assert.NoError(t, hook.errs[0])
+		assert.ErrorIs(t, hook.errs[1], ErrNotFound)
+	}
+	assert.Equal(t, []string{"CreateRecord", "GetFreeIP"}, hook.finished)
+	assert.Equal(t, []string{"CreateRecord", "GetFreeIP"}, counter.finished)
+}
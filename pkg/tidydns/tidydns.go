@@ -3,9 +3,11 @@ package tidydns
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"log/slog"
 	"net/http"
+	"net/netip"
 	"net/url"
 	"strconv"
 	"strings"
@@ -14,14 +16,84 @@ import (
 
 type TidyDNSClient interface {
 	GetSubnetIDs(ctx context.Context, subnetCIDR string) (*SubnetIDs, error)
+	// ListSubnets returns the DHCP subnets selected by filter.
+	ListSubnets(ctx context.Context, filter SubnetFilter) ([]*Subnet, error)
+	GetSubnet(ctx context.Context, subnetID int) (*Subnet, error)
+	// GetSubnetByPrefix returns the DHCP subnet with the given network.
+	GetSubnetByPrefix(ctx context.Context, prefix netip.Prefix) (*Subnet, error)
+	// FindSubnetForIP returns the most specific DHCP subnet containing an
+	// IPv4 or IPv6 address.
+	FindSubnetForIP(ctx context.Context, addr netip.Addr) (*Subnet, error)
+	// CreateSubnet creates a DHCP subnet and returns its ID. It fails with
+	// a *SubnetOverlapError if the network overlaps an existing subnet.
+	CreateSubnet(ctx context.Context, prefix netip.Prefix, settings SubnetSettings) (int, error)
+	// UpdateSubnet changes the settings of a DHCP subnet. Its network
+	// cannot be changed.
+	UpdateSubnet(ctx context.Context, subnetID int, settings SubnetSettings) error
+	DeleteSubnet(ctx context.Context, subnetID int) error
+	// SubnetUsage returns the address utilization of a DHCP subnet.
+	SubnetUsage(ctx context.Context, subnetID int) (*SubnetUsage, error)
+	// ListSubnetUsage returns the address utilization of the DHCP subnets
+	// selected by filter, reading the subnets concurrently.
+	ListSubnetUsage(ctx context.Context, filter SubnetFilter) ([]*SubnetUsage, error)
 	GetFreeIP(ctx context.Context, subnetID int) (string, error)
 	ListDHCPInterfaces(ctx context.Context, subnetID int) ([]*InterfaceInfo, error)
 	CreateDHCPInterface(ctx context.Context, createInfo CreateInfo) (int, error)
+	// AllocateInterface creates a DHCP interface on a free address of a
+	// subnet. If a concurrent caller takes the address first, another
+	// address is tried, up to opts.MaxAttempts times. If the address was
+	// taken by an interface of the same name, e.g. stored by a retried
+	// request, that interface is returned instead.
+	AllocateInterface(ctx context.Context, subnetID int, name string, opts AllocateOptions) (*InterfaceInfo, error)
 	ReadDHCPInterface(ctx context.Context, interfaceID int) (*InterfaceInfo, error)
 	UpdateDHCPInterfaceName(ctx context.Context, interfaceID int, interfaceName string) (int, error)
 	DeleteDHCPInterface(ctx context.Context, interfaceID int) error
 	ListZones(ctx context.Context) ([]*ZoneInfo, error)
+	// FindZoneID returns the ID of the zone with the given name. For alias
+	// zones, the ID of the zone they mirror is returned, as records of an
+	// alias zone are managed in that zone.
 	FindZoneID(ctx context.Context, name string) (int, error)
+	GetZone(ctx context.Context, zoneID int) (*Zone, error)
+	// ListZonesDetailed lists zones like ListZones, but with their full
+	// configuration and state.
+	ListZonesDetailed(ctx context.Context) ([]*Zone, error)
+	CreateZone(ctx context.Context, name string, settings ZoneSettings) (int, error)
+	UpdateZone(ctx context.Context, zoneID int, settings ZoneSettings) error
+	DeleteZone(ctx context.Context, zoneID int) error
+	// ListZoneAliases returns the alias zones mirroring a zone.
+	ListZoneAliases(ctx context.Context, zoneID int) ([]*Zone, error)
+	// CreateZoneAlias creates an alias zone mirroring the records of the
+	// target zone and returns the ID of the alias zone.
+	CreateZoneAlias(ctx context.Context, name string, targetZoneID int) (int, error)
+	// DeleteZoneAlias deletes an alias zone. It fails with
+	// ErrInvalidArgument if the zone is not an alias.
+	DeleteZoneAlias(ctx context.Context, aliasID int) error
+	// WaitForProvisioned polls a zone until a change has been provisioned
+	// to the name servers. TidyDNS does not report failed provisioning in a
+	// known way, so a zone that is never provisioned is polled until the
+	// context or the timeout expires. If the wait ends after the zone was
+	// read, the zone last read is returned with a *ProvisionError carrying
+	// its provision log.
+	WaitForProvisioned(ctx context.Context, zoneID int, opts WaitOptions) (*Zone, error)
+	GetZoneServerConfig(ctx context.Context, zoneID int) (*ZoneServerConfig, error)
+	// UpdateZoneServerConfig replaces the masters, forwarders and allowed
+	// transfers of a zone.
+	UpdateZoneServerConfig(ctx context.Context, zoneID int, config ZoneServerConfig) error
+	// SetDNSSEC enables or disables signing of a zone.
+	SetDNSSEC(ctx context.Context, zoneID int, enabled bool) error
+	// GenerateDNSSECKeys makes TidyDNS generate new signing keys for a zone
+	// when it is next provisioned.
+	GenerateDNSSECKeys(ctx context.Context, zoneID int) error
+	GetDNSSECStatus(ctx context.Context, zoneID int) (*DNSSEC, error)
+	// FindReverseZoneForIP returns the most specific reverse zone covering
+	// an IPv4 or IPv6 address.
+	FindReverseZoneForIP(ctx context.Context, addr netip.Addr) (*Zone, error)
+	// EnsurePTR creates or updates the PTR record of an address in its
+	// reverse zone to point at fqdn and returns the ID of the record. The
+	// client must be created with WithPTRRecordType. If the address has
+	// several PTR records, none is changed and the error matches
+	// ErrConflict.
+	EnsurePTR(ctx context.Context, addr netip.Addr, fqdn string) (int, error)
 	CreateRecord(ctx context.Context, zoneID int, info RecordInfo) (int, error)
 	UpdateRecord(ctx context.Context, zoneID int, recordID int, info RecordInfo) error
 	ReadRecord(ctx context.Context, zoneID int, recordID int) (*RecordInfo, error)
@@ -37,6 +109,9 @@ type TidyDNSClient interface {
 type ZoneInfo struct {
 	ID   int
 	Name string
+	// AliasID is the ID of the zone an alias zone mirrors, or 0 if the zone
+	// is not an alias.
+	AliasID int
 }
 
 type SubnetIDs struct {
@@ -60,14 +135,23 @@ type CreateInfo struct {
 }
 
 type RecordInfo struct {
-	ID          int
-	Type        RecordType
+	ID   int
+	Type RecordType
+	// TypeName is the name of the record type as reported by TidyDNS. It is
+	// ignored when creating or updating records.
+	TypeName    string
 	Name        string
 	Description string
 	Destination string
 	TTL         int
 	Status      RecordStatus
 	Location    LocationID
+	// Data is the structured content of MX, SRV, CAA, TLSA, SSHFP and DS
+	// records. When set, it replaces Destination on create and update and
+	// must match Type. It is nil when reading records of other types or
+	// records whose content cannot be parsed, such as an MX record without
+	// a preference; Destination still holds the content as read.
+	Data RecordData
 }
 
 type UserInfo struct {
@@ -114,6 +198,12 @@ const (
 	RecordTypeSSHFP RecordType = 8
 	RecordTypeTLSA  RecordType = 9
 	RecordTypeCAA   RecordType = 10
+	// RecordTypeUnknown is reported for records of a type not known by this
+	// package, such as PTR or AAAA records. The name TidyDNS uses for the
+	// type is kept in RecordInfo.TypeName. Such records can still be
+	// found and created by passing the number TidyDNS uses for the type,
+	// e.g. RecordType(n).
+	RecordTypeUnknown RecordType = -1
 
 	AuthGroupUser       AuthGroup = 2
 	AuthGroupSuperAdmin AuthGroup = 1
@@ -122,15 +212,25 @@ const (
 const errorTidyDNS = "error from tidyDNS server: %s"
 const headerContentType = "Content-Type"
 const mimeForm = "application/x-www-form-urlencoded"
+const headerUserAgent = "User-Agent"
 
 type tidyDNSClient struct {
-	client   *http.Client
-	username string
-	password string
-	baseURL  string
+	client        *http.Client
+	username      string
+	password      string
+	baseURL       string
+	userAgent     string
+	retry         RetryPolicy
+	throttle      throttle
+	logger        *slog.Logger
+	metricsHooks  []MetricsHook
+	ptrRecordType *RecordType
 }
 
-func (c *tidyDNSClient) CreateInternalUser(ctx context.Context, username string, password string, description string, changePasswordOnFirstLogin bool, authGroup AuthGroup, userAllow []UserAllowID) (UserID, error) {
+func (c *tidyDNSClient) CreateInternalUser(ctx context.Context, username string, password string, description string, changePasswordOnFirstLogin bool, authGroup AuthGroup, userAllow []UserAllowID) (_ UserID, err error) {
+	ctx, end := c.startOperation(ctx, "CreateInternalUser")
+	defer func() { end(err) }()
+
 	var userAllowFormatted []string
 	if len(userAllow) > 0 {
 		userAllowFormatted = make([]string, 0, len(userAllowFormatted))
@@ -169,25 +269,13 @@ func (c *tidyDNSClient) CreateInternalUser(ctx context.Context, username string,
 	if err != nil {
 		return 0, err
 	}
-	req.SetBasicAuth(c.username, c.password)
 	req.Header.Set(headerContentType, mimeForm)
 
-	res, err := c.client.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		return 0, err
 	}
 	defer closeResponse(res)
-	if res.StatusCode != http.StatusOK {
-		bodyBytes, err := io.ReadAll(res.Body)
-		bodyString := string(bodyBytes)
-		if err != nil {
-			return 0, err
-		}
-		if strings.Contains(bodyString, fmt.Sprintf("Key (username)=(%s) already exists", username)) {
-			return 0, fmt.Errorf("username already exists")
-		}
-		return 0, fmt.Errorf(errorTidyDNS, res.Status)
-	}
 
 	var user userCreate
 	err = json.NewDecoder(res.Body).Decode(&user)
@@ -198,7 +286,10 @@ func (c *tidyDNSClient) CreateInternalUser(ctx context.Context, username string,
 	return UserID(user.Data.Id), nil
 }
 
-func (c *tidyDNSClient) GetInternalUser(ctx context.Context, userID UserID) (*UserInfo, error) {
+func (c *tidyDNSClient) GetInternalUser(ctx context.Context, userID UserID) (_ *UserInfo, err error) {
+	ctx, end := c.startOperation(ctx, "GetInternalUser", attrUserID.Int(int(userID)))
+	defer func() { end(err) }()
+
 	userLookupUrl := fmt.Sprintf("%s/=/user/%s", c.baseURL, strconv.Itoa(int(userID)))
 	req, err := http.NewRequestWithContext(
 		ctx,
@@ -210,16 +301,11 @@ func (c *tidyDNSClient) GetInternalUser(ctx context.Context, userID UserID) (*Us
 		return nil, err
 	}
 
-	req.SetBasicAuth(c.username, c.password)
-
-	res, err := c.client.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer closeResponse(res)
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(errorTidyDNS, res.Status)
-	}
 
 	var user userRead
 	err = json.NewDecoder(res.Body).Decode(&user)
@@ -237,14 +323,9 @@ func (c *tidyDNSClient) GetInternalUser(ctx context.Context, userID UserID) (*Us
 		return nil, err
 	}
 
-	var ag AuthGroup
-	switch user.AuthGroup {
-	case "User":
-		ag = AuthGroupUser
-	case "SuperAdmin":
-		ag = AuthGroupSuperAdmin
-	default:
-		return nil, fmt.Errorf("unknown auth group")
+	ag, err := ParseAuthGroup(user.AuthGroup)
+	if err != nil {
+		return nil, err
 	}
 
 	return &UserInfo{
@@ -260,7 +341,10 @@ func (c *tidyDNSClient) GetInternalUser(ctx context.Context, userID UserID) (*Us
 	}, nil
 }
 
-func (c *tidyDNSClient) UpdateInternalUser(ctx context.Context, userID UserID, password *string, description *string, authGroup *AuthGroup, userAllow []UserAllowID) error {
+func (c *tidyDNSClient) UpdateInternalUser(ctx context.Context, userID UserID, password *string, description *string, authGroup *AuthGroup, userAllow []UserAllowID) (err error) {
+	ctx, end := c.startOperation(ctx, "UpdateInternalUser", attrUserID.Int(int(userID)))
+	defer func() { end(err) }()
+
 	data := url.Values{}
 
 	if password != nil {
@@ -299,17 +383,13 @@ func (c *tidyDNSClient) UpdateInternalUser(ctx context.Context, userID UserID, p
 	if err != nil {
 		return err
 	}
-	req.SetBasicAuth(c.username, c.password)
 	req.Header.Set(headerContentType, mimeForm)
 
-	res, err := c.client.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		return err
 	}
 	defer closeResponse(res)
-	if res.StatusCode != http.StatusOK {
-		return fmt.Errorf(errorTidyDNS, res.Status)
-	}
 
 	var user userCreate
 	err = json.NewDecoder(res.Body).Decode(&user)
@@ -320,7 +400,10 @@ func (c *tidyDNSClient) UpdateInternalUser(ctx context.Context, userID UserID, p
 	return nil
 }
 
-func (c *tidyDNSClient) DeleteInternalUser(ctx context.Context, userID UserID) error {
+func (c *tidyDNSClient) DeleteInternalUser(ctx context.Context, userID UserID) (err error) {
+	ctx, end := c.startOperation(ctx, "DeleteInternalUser", attrUserID.Int(int(userID)))
+	defer func() { end(err) }()
+
 	userLookupUrl := fmt.Sprintf("%s/=/user/%s", c.baseURL, strconv.Itoa(int(userID)))
 	req, err := http.NewRequestWithContext(
 		ctx,
@@ -332,16 +415,11 @@ func (c *tidyDNSClient) DeleteInternalUser(ctx context.Context, userID UserID) e
 		return err
 	}
 
-	req.SetBasicAuth(c.username, c.password)
-
-	res, err := c.client.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		return err
 	}
 	defer closeResponse(res)
-	if res.StatusCode != http.StatusOK {
-		return fmt.Errorf(errorTidyDNS, res.Status)
-	}
 
 	return nil
 }
@@ -355,13 +433,127 @@ func New(baseURL, username, password string) TidyDNSClient {
 	}
 }
 
+// NewWithOptions creates a client like New but lets the underlying HTTP
+// client, transport, TLS settings, proxy and user agent be configured.
+func NewWithOptions(baseURL, username, password string, opts ...Option) (TidyDNSClient, error) {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	client, err := cfg.httpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return &tidyDNSClient{
+		baseURL:   baseURL,
+		username:  username,
+		password:  password,
+		client:    client,
+		userAgent: cfg.userAgent,
+		retry:     cfg.retry,
+		throttle: throttle{
+			limiter:  cfg.limiter,
+			inFlight: cfg.inFlight,
+		},
+		logger:        cfg.logger,
+		metricsHooks:  cfg.metricsHooks,
+		ptrRecordType: cfg.ptrRecordType,
+	}, nil
+}
+
 func closeResponse(resp *http.Response) {
 	if resp != nil {
 		_ = resp.Body.Close()
 	}
 }
 
-func (c *tidyDNSClient) GetSubnetIDs(ctx context.Context, subnetCIDR string) (*SubnetIDs, error) {
+// do sends a request authenticated with the client credentials, retrying
+// transient failures according to the retry policy. Responses other than
+// 200 OK are consumed and returned as an *APIError.
+func (c *tidyDNSClient) do(req *http.Request) (*http.Response, error) {
+	req.SetBasicAuth(c.username, c.password)
+	if c.userAgent != "" {
+		req.Header.Set(headerUserAgent, c.userAgent)
+	}
+	if req.Header.Get(headerRequestID) == "" {
+		req.Header.Set(headerRequestID, newRequestID())
+	}
+
+	for attempt := 1; ; attempt++ {
+		res, err := c.send(req, attempt)
+		delay, retry := c.retry.backoff(req, attempt, err)
+		if !retry {
+			return res, err
+		}
+
+		if c.retry.OnRetry != nil {
+			event := RetryEvent{
+				Method:  req.Method,
+				Path:    req.URL.Path,
+				Attempt: attempt,
+				Err:     err,
+				Delay:   delay,
+			}
+			var apiErr *APIError
+			if errors.As(err, &apiErr) {
+				event.StatusCode = apiErr.StatusCode
+			}
+			c.retry.OnRetry(event)
+		}
+
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// send performs a single attempt of a request.
+func (c *tidyDNSClient) send(req *http.Request, attempt int) (*http.Response, error) {
+	if attempt > 1 {
+		var err error
+		req, err = rewind(req)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	release, err := c.throttle.acquire(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	req, end := c.startRequest(req, attempt)
+	c.logRequest(req, attempt)
+	start := time.Now()
+	res, err := c.client.Do(req)
+	if err != nil {
+		release()
+		end(0, err)
+		c.logResult(req, attempt, 0, time.Since(start), err)
+		return nil, err
+	}
+	res.Body = &releaseBody{ReadCloser: res.Body, release: release}
+	if res.StatusCode != http.StatusOK {
+		defer closeResponse(res)
+		apiErr := newAPIError(res)
+		end(res.StatusCode, apiErr)
+		c.logResult(req, attempt, res.StatusCode, time.Since(start), apiErr)
+		c.logBody(req, apiErr.Body)
+		return nil, apiErr
+	}
+	end(res.StatusCode, nil)
+	c.logResult(req, attempt, res.StatusCode, time.Since(start), nil)
+	c.logResponseBody(req, res)
+
+	return res, nil
+}
+
+func (c *tidyDNSClient) GetSubnetIDs(ctx context.Context, subnetCIDR string) (_ *SubnetIDs, err error) {
+	ctx, end := c.startOperation(ctx, "GetSubnetIDs", attrSubnet.String(subnetCIDR))
+	defer func() { end(err) }()
+
 	dhcpSubnetUrl := fmt.Sprintf("%s/=/dhcp_subnet?subnet=%s", c.baseURL, subnetCIDR)
 	req, err := http.NewRequestWithContext(
 		ctx,
@@ -373,16 +565,11 @@ func (c *tidyDNSClient) GetSubnetIDs(ctx context.Context, subnetCIDR string) (*S
 		return nil, err
 	}
 
-	req.SetBasicAuth(c.username, c.password)
-
-	res, err := c.client.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer closeResponse(res)
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(errorTidyDNS, res.Status)
-	}
 
 	var subnets []dhcpSubnet
 	err = json.NewDecoder(res.Body).Decode(&subnets)
@@ -391,7 +578,7 @@ func (c *tidyDNSClient) GetSubnetIDs(ctx context.Context, subnetCIDR string) (*S
 	}
 
 	if len(subnets) == 0 {
-		return nil, fmt.Errorf("subnet not found: %s", subnetCIDR)
+		return nil, fmt.Errorf("%w: subnet %s", ErrNotFound, subnetCIDR)
 	}
 
 	if len(subnets) > 1 {
@@ -405,7 +592,10 @@ func (c *tidyDNSClient) GetSubnetIDs(ctx context.Context, subnetCIDR string) (*S
 	}, nil
 }
 
-func (c *tidyDNSClient) GetFreeIP(ctx context.Context, subnetID int) (string, error) {
+func (c *tidyDNSClient) GetFreeIP(ctx context.Context, subnetID int) (_ string, err error) {
+	ctx, end := c.startOperation(ctx, "GetFreeIP", attrSubnetID.Int(subnetID))
+	defer func() { end(err) }()
+
 	dhcpFreeIPUrl := fmt.Sprintf("%s/=/dhcp_subnet_free_ip/%d", c.baseURL, subnetID)
 	req, err := http.NewRequestWithContext(
 		ctx,
@@ -417,16 +607,11 @@ func (c *tidyDNSClient) GetFreeIP(ctx context.Context, subnetID int) (string, er
 		return "", err
 	}
 
-	req.SetBasicAuth(c.username, c.password)
-
-	res, err := c.client.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		return "", err
 	}
 	defer closeResponse(res)
-	if res.StatusCode != http.StatusOK {
-		return "", fmt.Errorf(errorTidyDNS, res.Status)
-	}
 
 	var freeIP dhcpFreeIP
 	err = json.NewDecoder(res.Body).Decode(&freeIP)
@@ -437,10 +622,13 @@ func (c *tidyDNSClient) GetFreeIP(ctx context.Context, subnetID int) (string, er
 	return freeIP.Data.IPAddress, nil
 }
 
-func (c *tidyDNSClient) ListDHCPInterfaces(ctx context.Context, subnetID int) ([]*InterfaceInfo, error) {
+func (c *tidyDNSClient) ListDHCPInterfaces(ctx context.Context, subnetID int) (_ []*InterfaceInfo, err error) {
+	ctx, end := c.startOperation(ctx, "ListDHCPInterfaces", attrSubnetID.Int(subnetID))
+	defer func() { end(err) }()
+
 	var interfaces []interfaceRead
 	dhcpInterfaceUrl := fmt.Sprintf("%s/=/dhcp_interface/?subnet_id=%d&type=json", c.baseURL, subnetID)
-	err := c.getData(
+	err = c.getData(
 		ctx,
 		dhcpInterfaceUrl,
 		&interfaces,
@@ -460,7 +648,10 @@ func (c *tidyDNSClient) ListDHCPInterfaces(ctx context.Context, subnetID int) ([
 	return result, nil
 }
 
-func (c *tidyDNSClient) CreateDHCPInterface(ctx context.Context, createInfo CreateInfo) (int, error) {
+func (c *tidyDNSClient) CreateDHCPInterface(ctx context.Context, createInfo CreateInfo) (_ int, err error) {
+	ctx, end := c.startOperation(ctx, "CreateDHCPInterface", attrSubnetID.Int(createInfo.SubnetID), attrZoneID.Int(createInfo.ZoneID))
+	defer func() { end(err) }()
+
 	data := url.Values{
 		"subnet_id":   {strconv.Itoa(createInfo.SubnetID)},
 		"zone_id":     {strconv.Itoa(createInfo.ZoneID)},
@@ -469,8 +660,6 @@ func (c *tidyDNSClient) CreateDHCPInterface(ctx context.Context, createInfo Crea
 		"location_id": {strconv.Itoa(createInfo.LocationID)},
 	}
 
-	var checkstring = fmt.Sprintf("Key (destination)=(%s) already exists", createInfo.InterfaceIP)
-
 	dhcpInterfaceNewUrl := fmt.Sprintf("%s/=/dhcp_interface//new", c.baseURL)
 	req, err := http.NewRequestWithContext(
 		ctx,
@@ -481,27 +670,14 @@ func (c *tidyDNSClient) CreateDHCPInterface(ctx context.Context, createInfo Crea
 	if err != nil {
 		return 0, err
 	}
-	req.SetBasicAuth(c.username, c.password)
 	req.Header.Set(headerContentType, mimeForm)
 
-	res, err := c.client.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		return 0, err
 	}
 	defer closeResponse(res)
 
-	if res.StatusCode != http.StatusOK {
-		bodyBytes, err := io.ReadAll(res.Body)
-		bodyString := string(bodyBytes)
-		if err != nil {
-			return 0, err
-		}
-		if strings.Contains(bodyString, checkstring) {
-			return 1, fmt.Errorf("try again")
-		}
-		return 0, fmt.Errorf(errorTidyDNS, res.Status)
-	}
-
 	var createResp interfaceCreate
 	err = json.NewDecoder(res.Body).Decode(&createResp)
 	if err != nil {
@@ -511,7 +687,10 @@ func (c *tidyDNSClient) CreateDHCPInterface(ctx context.Context, createInfo Crea
 	return createResp.ID, nil
 }
 
-func (c *tidyDNSClient) ReadDHCPInterface(ctx context.Context, interfaceID int) (*InterfaceInfo, error) {
+func (c *tidyDNSClient) ReadDHCPInterface(ctx context.Context, interfaceID int) (_ *InterfaceInfo, err error) {
+	ctx, end := c.startOperation(ctx, "ReadDHCPInterface", attrInterfaceID.Int(interfaceID))
+	defer func() { end(err) }()
+
 	dhcpInterfaceReadUrl := fmt.Sprintf("%s/=/dhcp_interface/?id=%d", c.baseURL, interfaceID)
 	req, err := http.NewRequestWithContext(
 		ctx,
@@ -523,16 +702,11 @@ func (c *tidyDNSClient) ReadDHCPInterface(ctx context.Context, interfaceID int)
 		return nil, err
 	}
 
-	req.SetBasicAuth(c.username, c.password)
-
-	res, err := c.client.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer closeResponse(res)
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(errorTidyDNS, res.Status)
-	}
 
 	var interfaceRead interfaceRead
 	err = json.NewDecoder(res.Body).Decode(&interfaceRead)
@@ -547,7 +721,10 @@ func (c *tidyDNSClient) ReadDHCPInterface(ctx context.Context, interfaceID int)
 	}, nil
 }
 
-func (c *tidyDNSClient) UpdateDHCPInterfaceName(ctx context.Context, interfaceID int, interfaceName string) (int, error) {
+func (c *tidyDNSClient) UpdateDHCPInterfaceName(ctx context.Context, interfaceID int, interfaceName string) (_ int, err error) {
+	ctx, end := c.startOperation(ctx, "UpdateDHCPInterfaceName", attrInterfaceID.Int(interfaceID))
+	defer func() { end(err) }()
+
 	data := url.Values{
 		"name": {interfaceName},
 	}
@@ -562,17 +739,13 @@ func (c *tidyDNSClient) UpdateDHCPInterfaceName(ctx context.Context, interfaceID
 	if err != nil {
 		return 0, err
 	}
-	req.SetBasicAuth(c.username, c.password)
 	req.Header.Set(headerContentType, mimeForm)
 
-	res, err := c.client.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		return 0, err
 	}
 	defer closeResponse(res)
-	if res.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf(errorTidyDNS, res.Status)
-	}
 
 	var createResp interfaceCreate
 	err = json.NewDecoder(res.Body).Decode(&createResp)
@@ -583,7 +756,10 @@ func (c *tidyDNSClient) UpdateDHCPInterfaceName(ctx context.Context, interfaceID
 	return createResp.ID, nil
 }
 
-func (c *tidyDNSClient) DeleteDHCPInterface(ctx context.Context, interfaceID int) error {
+func (c *tidyDNSClient) DeleteDHCPInterface(ctx context.Context, interfaceID int) (err error) {
+	ctx, end := c.startOperation(ctx, "DeleteDHCPInterface", attrInterfaceID.Int(interfaceID))
+	defer func() { end(err) }()
+
 	dhcpInterfaceLookupUrl := fmt.Sprintf("%s/=/dhcp_interface/%d", c.baseURL, interfaceID)
 	req, err := http.NewRequestWithContext(
 		ctx,
@@ -595,24 +771,22 @@ func (c *tidyDNSClient) DeleteDHCPInterface(ctx context.Context, interfaceID int
 		return err
 	}
 
-	req.SetBasicAuth(c.username, c.password)
-
-	res, err := c.client.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		return err
 	}
 	defer closeResponse(res)
-	if res.StatusCode != http.StatusOK {
-		return fmt.Errorf(errorTidyDNS, res.Status)
-	}
 
 	return nil
 }
 
-func (c *tidyDNSClient) ListZones(ctx context.Context) ([]*ZoneInfo, error) {
+func (c *tidyDNSClient) ListZones(ctx context.Context) (_ []*ZoneInfo, err error) {
+	ctx, end := c.startOperation(ctx, "ListZones")
+	defer func() { end(err) }()
+
 	var zones []zoneInfo
 	zoneListUrl := fmt.Sprintf("%s/=/zone?type=json", c.baseURL)
-	err := c.getData(
+	err = c.getData(
 		ctx,
 		zoneListUrl,
 		&zones,
@@ -624,14 +798,30 @@ func (c *tidyDNSClient) ListZones(ctx context.Context) ([]*ZoneInfo, error) {
 	result := make([]*ZoneInfo, 0)
 	for _, zone := range zones {
 		result = append(result, &ZoneInfo{
-			ID:   zone.ID,
-			Name: zone.Name,
+			ID:      zone.ID,
+			Name:    zone.Name,
+			AliasID: zone.aliasID(),
 		})
 	}
 	return result, nil
 }
 
-func (c *tidyDNSClient) FindZoneID(ctx context.Context, name string) (int, error) {
+func (c *tidyDNSClient) FindZoneID(ctx context.Context, name string) (_ int, err error) {
+	ctx, end := c.startOperation(ctx, "FindZoneID", attrZoneName.String(name))
+	defer func() { end(err) }()
+
+	zone, err := c.findZone(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	if aliasID := zone.aliasID(); aliasID != 0 {
+		return aliasID, nil
+	}
+	return zone.ID, nil
+}
+
+// findZone looks up a zone by name without resolving aliases.
+func (c *tidyDNSClient) findZone(ctx context.Context, name string) (*zoneInfo, error) {
 	var zones []zoneInfo
 	zoneLookupUrl := fmt.Sprintf("%s/=/zone?type=json&name=%s", c.baseURL, name)
 	err := c.getData(
@@ -640,32 +830,43 @@ func (c *tidyDNSClient) FindZoneID(ctx context.Context, name string) (int, error
 		&zones,
 	)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	if len(zones) == 0 {
-		return 0, fmt.Errorf("zone not found for: %s", name)
+		return nil, fmt.Errorf("%w: zone %s", ErrNotFound, name)
 	}
 
 	for _, z := range zones {
 		if z.Name == name {
-			return z.ID, nil
+			return &z, nil
 		}
 	}
 
-	return 0, fmt.Errorf("unable to match zone name: %s", name)
+	return nil, fmt.Errorf("%w: unable to match zone name %s", ErrNotFound, name)
 }
 
-func (c *tidyDNSClient) CreateRecord(ctx context.Context, zoneID int, info RecordInfo) (int, error) {
+func (c *tidyDNSClient) CreateRecord(ctx context.Context, zoneID int, info RecordInfo) (_ int, err error) {
+	ctx, end := c.startOperation(ctx, "CreateRecord", attrZoneID.Int(zoneID), attrRecordType.String(info.Type.String()))
+	defer func() { end(err) }()
+
+	if info.Type < 0 {
+		return 0, fmt.Errorf("%w: unknown record type %d", ErrInvalidArgument, info.Type)
+	}
+	fields, err := info.fields(true)
+	if err != nil {
+		return 0, err
+	}
+
 	data := url.Values{
 		"type":        {strconv.Itoa(int(info.Type))},
 		"name":        {info.Name},
 		"ttl":         {strconv.Itoa(info.TTL)},
 		"description": {info.Description},
 		"status":      {strconv.Itoa(int(info.Status))},
-		"destination": {info.Destination},
 		"location_id": {strconv.Itoa(int(info.Location))},
 	}
+	fields.form(data)
 
 	newRecordUrl := fmt.Sprintf("%s/=/record/new/%d", c.baseURL, zoneID)
 	req, err := http.NewRequestWithContext(
@@ -677,48 +878,29 @@ func (c *tidyDNSClient) CreateRecord(ctx context.Context, zoneID int, info Recor
 	if err != nil {
 		return 0, err
 	}
-	req.SetBasicAuth(c.username, c.password)
 	req.Header.Set(headerContentType, mimeForm)
 
-	res, err := c.client.Do(req)
+	res, err := c.do(req)
 	if err != nil || res == nil {
 		return 0, err
 	}
-	defer closeResponse(res)
-	if res.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf(errorTidyDNS, res.Status)
-	}
+	// Release the response before looking up the record, so the lookup is
+	// not blocked by a limit on requests in flight.
+	closeResponse(res)
 
+	var records []recordList
 	recordMergeUrl := fmt.Sprintf("%s/=/record_merged?type=json&zone_id=%d&showall=1", c.baseURL, zoneID)
-	req, err = http.NewRequestWithContext(
+	err = c.getData(
 		ctx,
-		"GET",
 		recordMergeUrl,
-		nil,
+		&records,
 	)
 	if err != nil {
 		return 0, err
 	}
 
-	req.SetBasicAuth(c.username, c.password)
-
-	res, err = c.client.Do(req)
-	if err != nil || res == nil {
-		return 0, err
-	}
-	defer closeResponse(res)
-	if res.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf(errorTidyDNS, res.Status)
-	}
-
-	var records []recordList
-	err = json.NewDecoder(res.Body).Decode(&records)
-	if err != nil {
-		return 0, err
-	}
-
 	for _, r := range records {
-		if r.Type == info.Type && r.Name == info.Name && r.Destination == info.Destination {
+		if r.Type == info.Type && r.Name == info.Name && r.Destination == fields.destination {
 			return r.ID, nil
 		}
 	}
@@ -726,14 +908,22 @@ func (c *tidyDNSClient) CreateRecord(ctx context.Context, zoneID int, info Recor
 	return 0, fmt.Errorf("unable to find new record")
 }
 
-func (c *tidyDNSClient) UpdateRecord(ctx context.Context, zoneID int, recordID int, info RecordInfo) error {
+func (c *tidyDNSClient) UpdateRecord(ctx context.Context, zoneID int, recordID int, info RecordInfo) (err error) {
+	ctx, end := c.startOperation(ctx, "UpdateRecord", attrZoneID.Int(zoneID), attrRecordID.Int(recordID))
+	defer func() { end(err) }()
+
+	fields, err := info.fields(false)
+	if err != nil {
+		return err
+	}
+
 	data := url.Values{
 		"ttl":         {strconv.Itoa(info.TTL)},
 		"description": {info.Description},
 		"status":      {strconv.Itoa(int(info.Status))},
-		"destination": {info.Destination},
 		"location_id": {strconv.Itoa(int(info.Location))},
 	}
+	fields.form(data)
 
 	zoneLookupUrl := fmt.Sprintf("%s/=/record/%d/%d", c.baseURL, recordID, zoneID)
 	req, err := http.NewRequestWithContext(
@@ -745,25 +935,24 @@ func (c *tidyDNSClient) UpdateRecord(ctx context.Context, zoneID int, recordID i
 	if err != nil {
 		return err
 	}
-	req.SetBasicAuth(c.username, c.password)
 	req.Header.Set(headerContentType, mimeForm)
 
-	res, err := c.client.Do(req)
+	res, err := c.do(req)
 	if err != nil || res == nil {
 		return err
 	}
 	defer closeResponse(res)
-	if res.StatusCode != http.StatusOK {
-		return fmt.Errorf(errorTidyDNS, res.Status)
-	}
 
 	return nil
 }
 
-func (c *tidyDNSClient) FindRecord(ctx context.Context, zoneID int, name string, rType RecordType) ([]*RecordInfo, error) {
+func (c *tidyDNSClient) FindRecord(ctx context.Context, zoneID int, name string, rType RecordType) (_ []*RecordInfo, err error) {
+	ctx, end := c.startOperation(ctx, "FindRecord", attrZoneID.Int(zoneID), attrRecordType.String(rType.String()))
+	defer func() { end(err) }()
+
 	var records []recordList
 	recordLookupUrl := fmt.Sprintf("%s/=/record?type=json&zone=%d&name=%s", c.baseURL, zoneID, name)
-	err := c.getData(
+	err = c.getData(
 		ctx,
 		recordLookupUrl,
 		&records,
@@ -774,25 +963,20 @@ func (c *tidyDNSClient) FindRecord(ctx context.Context, zoneID int, name string,
 
 	result := make([]*RecordInfo, 0)
 	for _, r := range records {
-		if r.Type == rType && r.Name == name {
-			result = append(result, &RecordInfo{
-				ID:          r.ID,
-				Type:        r.Type,
-				Name:        r.Name,
-				Description: r.Description,
-				Destination: r.Destination,
-				TTL:         r.TTL,
-				Location:    r.Location,
-			})
+		if r.hasType(rType) && r.Name == name {
+			result = append(result, r.info())
 		}
 	}
 	return result, nil
 }
 
-func (c *tidyDNSClient) ListRecords(ctx context.Context, zoneID int) ([]*RecordInfo, error) {
+func (c *tidyDNSClient) ListRecords(ctx context.Context, zoneID int) (_ []*RecordInfo, err error) {
+	ctx, end := c.startOperation(ctx, "ListRecords", attrZoneID.Int(zoneID))
+	defer func() { end(err) }()
+
 	var records []recordList
 	recordMergeUrl := fmt.Sprintf("%s/=/record_merged?type=json&zone_id=%d&showall=1", c.baseURL, zoneID)
-	err := c.getData(
+	err = c.getData(
 		ctx,
 		recordMergeUrl,
 		&records,
@@ -803,23 +987,18 @@ func (c *tidyDNSClient) ListRecords(ctx context.Context, zoneID int) ([]*RecordI
 
 	result := make([]*RecordInfo, 0)
 	for _, r := range records {
-		result = append(result, &RecordInfo{
-			ID:          r.ID,
-			Type:        r.Type,
-			Name:        r.Name,
-			Description: r.Description,
-			Destination: r.Destination,
-			TTL:         r.TTL,
-			Location:    r.Location,
-		})
+		result = append(result, r.info())
 	}
 	return result, nil
 }
 
-func (c *tidyDNSClient) ReadRecord(ctx context.Context, zoneID int, recordID int) (*RecordInfo, error) {
+func (c *tidyDNSClient) ReadRecord(ctx context.Context, zoneID int, recordID int) (_ *RecordInfo, err error) {
+	ctx, end := c.startOperation(ctx, "ReadRecord", attrZoneID.Int(zoneID), attrRecordID.Int(recordID))
+	defer func() { end(err) }()
+
 	var record recordRead
 	recordLookupUrl := fmt.Sprintf("%s/=/record/%d/%d", c.baseURL, zoneID, recordID)
-	err := c.getData(
+	err = c.getData(
 		ctx,
 		recordLookupUrl,
 		&record,
@@ -828,19 +1007,25 @@ func (c *tidyDNSClient) ReadRecord(ctx context.Context, zoneID int, recordID int
 		return nil, err
 	}
 
+	t := resolveRecordType(record.Type, record.TypeName)
 	return &RecordInfo{
 		ID:          record.ID,
-		Type:        record.Type,
+		Type:        t,
+		TypeName:    record.TypeName,
 		Name:        record.Name,
 		Description: record.Description,
 		Destination: record.Destination,
 		TTL:         record.TTL,
 		Status:      record.Status,
 		Location:    record.Location,
+		Data:        decodeRecordData(t, recordFields{record.Destination, record.Value, record.Weight, record.Port}),
 	}, nil
 }
 
-func (c *tidyDNSClient) DeleteRecord(ctx context.Context, zoneID int, recordID int) error {
+func (c *tidyDNSClient) DeleteRecord(ctx context.Context, zoneID int, recordID int) (err error) {
+	ctx, end := c.startOperation(ctx, "DeleteRecord", attrZoneID.Int(zoneID), attrRecordID.Int(recordID))
+	defer func() { end(err) }()
+
 	recordLookupUrl := fmt.Sprintf("%s/=/record/%d/%d", c.baseURL, recordID, zoneID)
 	req, err := http.NewRequestWithContext(
 		ctx,
@@ -852,36 +1037,47 @@ func (c *tidyDNSClient) DeleteRecord(ctx context.Context, zoneID int, recordID i
 		return err
 	}
 
-	req.SetBasicAuth(c.username, c.password)
-
-	res, err := c.client.Do(req)
+	res, err := c.do(req)
 	if err != nil || res == nil {
 		return err
 	}
 	defer closeResponse(res)
-	if res.StatusCode != http.StatusOK {
-		return fmt.Errorf(errorTidyDNS, res.Status)
-	}
 
 	return nil
 }
 
+// postForm posts form encoded data and decodes the JSON response into value,
+// unless value is nil.
+func (c *tidyDNSClient) postForm(ctx context.Context, url string, data url.Values, value interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(data.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(headerContentType, mimeForm)
+
+	res, err := c.do(req)
+	if err != nil || res == nil {
+		return err
+	}
+	defer closeResponse(res)
+
+	if value == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(value)
+}
+
 func (c *tidyDNSClient) getData(ctx context.Context, url string, value interface{}) error {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return err
 	}
 
-	req.SetBasicAuth(c.username, c.password)
-
-	res, err := c.client.Do(req)
+	res, err := c.do(req)
 	if err != nil || res == nil {
 		return err
 	}
 	defer closeResponse(res)
-	if res.StatusCode != http.StatusOK {
-		return fmt.Errorf(errorTidyDNS, res.Status)
-	}
 
 	err = json.NewDecoder(res.Body).Decode(value)
 	if err != nil {
@@ -335,6 +335,34 @@ func TestFindRecord(t *testing.T) {
 	assert.Equal(t, 65377, info[0].ID)
 }
 
+func TestFindRecordUnknownType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte(`[
+			{"id": 1, "type": 0, "type_name": "A", "name": "host", "destination": "10.68.1.2", "status": "0"},
+			{"id": 2, "type": 42, "type_name": "AAAA", "name": "host", "destination": "2a01:4d0::1", "status": "0"},
+			{"id": 3, "type": 43, "type_name": "SPF", "name": "host", "destination": "v=spf1 -all", "status": "0"}
+		]`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	info, err := c.FindRecord(context.Background(), 2861, "host", RecordType(42))
+	assert.NoError(t, err)
+	if assert.Len(t, info, 1) {
+		assert.Equal(t, 2, info[0].ID)
+		assert.Equal(t, RecordTypeUnknown, info[0].Type)
+		assert.Equal(t, "AAAA", info[0].TypeName)
+	}
+
+	info, err = c.FindRecord(context.Background(), 2861, "host", RecordTypeUnknown)
+	assert.NoError(t, err)
+	assert.Len(t, info, 2)
+
+	info, err = c.FindRecord(context.Background(), 2861, "host", RecordType(44))
+	assert.NoError(t, err)
+	assert.Empty(t, info)
+}
+
 func TestListZones(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		assert.Equal(t, "GET", req.Method)
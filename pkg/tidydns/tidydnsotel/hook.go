@@ -0,0 +1,203 @@
+// Package tidydnsotel records TidyDNS client calls with OpenTelemetry.
+//
+// A Hook is a tidydns.MetricsHook starting a span for every client method,
+// with a child span for every HTTP request it sends, and recording request
+// count, latency and error metrics. Client methods called by other methods,
+// such as GetZone by WaitForProvisioned, do not get spans of their own; their
+// requests are children of the span of the outer method:
+//
+//	hook, err := tidydnsotel.NewHook(tidydnsotel.WithTracerProvider(tp), tidydnsotel.WithMeterProvider(mp))
+//	client, err := tidydns.NewWithOptions(url, user, pass, tidydns.WithMetricsHook(hook))
+package tidydnsotel
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/neticdk/tidydns-go/pkg/tidydns"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/neticdk/tidydns-go/pkg/tidydns/tidydnsotel"
+
+// Attribute keys used on spans and metrics besides the attributes of the
+// client methods.
+const (
+	attrOperation  = attribute.Key("tidydns.operation")
+	attrMethod     = attribute.Key("http.request.method")
+	attrStatusCode = attribute.Key("http.response.status_code")
+	attrURLPath    = attribute.Key("url.path")
+	attrErrorType  = attribute.Key("error.type")
+)
+
+// Option configures a Hook created by NewHook.
+type Option func(*config)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+}
+
+// WithTracerProvider sets the tracer provider spans are created with. It
+// defaults to the global tracer provider.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = provider
+	}
+}
+
+// WithMeterProvider sets the meter provider metrics are recorded with. It
+// defaults to the global meter provider.
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = provider
+	}
+}
+
+// Hook records TidyDNS client method calls and their HTTP requests as
+// OpenTelemetry spans and metrics.
+type Hook struct {
+	tracer            trace.Tracer
+	operations        metric.Int64Counter
+	operationErrors   metric.Int64Counter
+	operationDuration metric.Float64Histogram
+	requestDuration   metric.Float64Histogram
+	requestErrors     metric.Int64Counter
+}
+
+var _ tidydns.OperationHook = (*Hook)(nil)
+var _ tidydns.RequestHook = (*Hook)(nil)
+
+// NewHook creates a hook. It must be given to tidydns.WithMetricsHook to
+// record the calls of a client.
+func NewHook(opts ...Option) (*Hook, error) {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	tp := cfg.tracerProvider
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	mp := cfg.meterProvider
+	if mp == nil {
+		mp = otel.GetMeterProvider()
+	}
+
+	h := &Hook{
+		tracer: tp.Tracer(instrumentationName),
+	}
+	meter := mp.Meter(instrumentationName)
+
+	var err, e error
+	h.operations, e = meter.Int64Counter("tidydns.client.operations",
+		metric.WithDescription("Number of client method calls."))
+	err = errors.Join(err, e)
+	h.operationErrors, e = meter.Int64Counter("tidydns.client.operation.errors",
+		metric.WithDescription("Number of client method calls that returned an error."))
+	err = errors.Join(err, e)
+	h.operationDuration, e = meter.Float64Histogram("tidydns.client.operation.duration",
+		metric.WithDescription("Duration of client method calls."),
+		metric.WithUnit("s"))
+	err = errors.Join(err, e)
+	h.requestDuration, e = meter.Float64Histogram("http.client.request.duration",
+		metric.WithDescription("Duration of HTTP requests sent to TidyDNS."),
+		metric.WithUnit("s"))
+	err = errors.Join(err, e)
+	h.requestErrors, e = meter.Int64Counter("tidydns.client.request.errors",
+		metric.WithDescription("Number of HTTP requests that failed or were answered with an error status."))
+	err = errors.Join(err, e)
+	if err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// OperationStarted implements tidydns.MetricsHook.
+func (h *Hook) OperationStarted(operation string) {}
+
+// OperationFinished implements tidydns.MetricsHook.
+func (h *Hook) OperationFinished(operation string, duration time.Duration, err error) {
+	ctx := context.Background()
+	opAttrs := metric.WithAttributes(attrOperation.String(operation))
+	h.operations.Add(ctx, 1, opAttrs)
+	h.operationDuration.Record(ctx, duration.Seconds(), opAttrs)
+	if err != nil {
+		h.operationErrors.Add(ctx, 1, metric.WithAttributes(attrOperation.String(operation), attrErrorType.String(tidydns.ErrorClass(err))))
+	}
+}
+
+// StartOperation implements tidydns.OperationHook.
+func (h *Hook) StartOperation(ctx context.Context, operation string, attrs []slog.Attr) (context.Context, func(error)) {
+	ctx, span := h.tracer.Start(ctx, "tidydns."+operation,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(convertAttrs(attrs)...),
+	)
+
+	return ctx, func(err error) {
+		if err != nil {
+			var apiErr *tidydns.APIError
+			if errors.As(err, &apiErr) {
+				span.SetAttributes(attrStatusCode.Int(apiErr.StatusCode))
+			}
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+// StartRequest implements tidydns.RequestHook.
+func (h *Hook) StartRequest(req *http.Request, attempt int) (*http.Request, func(status int, err error)) {
+	start := time.Now()
+	ctx, span := h.tracer.Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attrMethod.String(req.Method),
+			attrURLPath.String(req.URL.Path),
+			attribute.Int("http.request.resend_count", attempt-1),
+		),
+	)
+
+	return req.WithContext(ctx), func(status int, err error) {
+		attrs := []attribute.KeyValue{attrMethod.String(req.Method)}
+		if status != 0 {
+			attrs = append(attrs, attrStatusCode.Int(status))
+			span.SetAttributes(attrStatusCode.Int(status))
+		}
+		h.requestDuration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+		if err != nil {
+			attrs = append(attrs, attrErrorType.String(tidydns.ErrorClass(err)))
+			h.requestErrors.Add(ctx, 1, metric.WithAttributes(attrs...))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+// convertAttrs converts the attributes of a client method to OpenTelemetry
+// attributes.
+func convertAttrs(attrs []slog.Attr) []attribute.KeyValue {
+	result := make([]attribute.KeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		key := attribute.Key(a.Key)
+		switch a.Value.Kind() {
+		case slog.KindInt64:
+			result = append(result, key.Int64(a.Value.Int64()))
+		case slog.KindBool:
+			result = append(result, key.Bool(a.Value.Bool()))
+		default:
+			result = append(result, key.String(a.Value.String()))
+		}
+	}
+	return result
+}
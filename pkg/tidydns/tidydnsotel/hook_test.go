@@ -0,0 +1,193 @@
+package tidydnsotel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/neticdk/tidydns-go/pkg/tidydns"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const (
+	readRecordListResponse = `[{"id": 64694, "type": 0, "type_name": "A", "name": "tal-test", "destination": "10.68.1.2", "ttl": 300, "status": "0"}]`
+	freeIPResponse         = `{"status":0,"data":{"ip_address":"10.68.0.134"}}`
+)
+
+func findMetric(rm metricdata.ResourceMetrics, name string) (metricdata.Metrics, bool) {
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				return m, true
+			}
+		}
+	}
+	return metricdata.Metrics{}, false
+}
+
+func TestTracingCreateRecord(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte(readRecordListResponse))
+	}))
+	defer server.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	hook, err := NewHook(WithTracerProvider(tp))
+	assert.NoError(t, err)
+	c, err := tidydns.NewWithOptions(server.URL, "username", "password", tidydns.WithMetricsHook(hook))
+	assert.NoError(t, err)
+	_, err = c.CreateRecord(context.Background(), 2861, tidydns.RecordInfo{
+		Type:        tidydns.RecordTypeA,
+		Name:        "tal-test",
+		Destination: "10.68.1.2",
+	})
+	assert.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 3)
+
+	parent := spans[2]
+	assert.Equal(t, "tidydns.CreateRecord", parent.Name)
+	assert.Contains(t, parent.Attributes, attribute.Int("tidydns.zone_id", 2861))
+	assert.Contains(t, parent.Attributes, attribute.String("tidydns.record_type", "A"))
+
+	assert.Equal(t, "HTTP POST", spans[0].Name)
+	assert.Equal(t, "HTTP GET", spans[1].Name)
+	for _, child := range spans[:2] {
+		assert.Equal(t, parent.SpanContext.SpanID(), child.Parent.SpanID())
+		assert.Contains(t, child.Attributes, attrStatusCode.Int(200))
+	}
+	assert.Contains(t, spans[0].Attributes, attrURLPath.String("/=/record/new/2861"))
+	assert.Contains(t, spans[1].Attributes, attrURLPath.String("/=/record_merged"))
+}
+
+func TestTracingNestedCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/=/dhcp_subnet/1185":
+			_, _ = rw.Write([]byte(`{"id": 1185, "subnet": "10.68.0.128/26", "zone_id": 2861}`))
+		case "/=/dhcp_subnet_free_ip/1185":
+			_, _ = rw.Write([]byte(freeIPResponse))
+		default:
+			_, _ = rw.Write([]byte(`{"status": 0, "id": 30641}`))
+		}
+	}))
+	defer server.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	hook, err := NewHook(WithTracerProvider(tp))
+	assert.NoError(t, err)
+	c, err := tidydns.NewWithOptions(server.URL, "username", "password", tidydns.WithMetricsHook(hook))
+	assert.NoError(t, err)
+	_, err = c.AllocateInterface(context.Background(), 1185, "node1", tidydns.AllocateOptions{})
+	assert.NoError(t, err)
+
+	// GetSubnet, GetFreeIP and CreateDHCPInterface are part of the
+	// AllocateInterface span rather than spans of their own.
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 4)
+	parent := spans[3]
+	assert.Equal(t, "tidydns.AllocateInterface", parent.Name)
+	assert.False(t, parent.Parent.IsValid())
+	for _, child := range spans[:3] {
+		assert.Contains(t, []string{"HTTP GET", "HTTP POST"}, child.Name)
+		assert.Equal(t, parent.SpanContext.SpanID(), child.Parent.SpanID())
+	}
+}
+
+func TestTracingError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	hook, err := NewHook(WithTracerProvider(tp))
+	assert.NoError(t, err)
+	c, err := tidydns.NewWithOptions(server.URL, "username", "password", tidydns.WithMetricsHook(hook))
+	assert.NoError(t, err)
+	_, err = c.GetFreeIP(context.Background(), 1185)
+	assert.Error(t, err)
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "tidydns.GetFreeIP", spans[1].Name)
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+	assert.Contains(t, spans[1].Attributes, attribute.Int("tidydns.subnet_id", 1185))
+	assert.Contains(t, spans[1].Attributes, attrStatusCode.Int(404))
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+}
+
+func TestMetrics(t *testing.T) {
+	fail := false
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if fail {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = rw.Write([]byte(freeIPResponse))
+	}))
+	defer server.Close()
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	hook, err := NewHook(WithMeterProvider(mp))
+	assert.NoError(t, err)
+	c, err := tidydns.NewWithOptions(server.URL, "username", "password", tidydns.WithMetricsHook(hook))
+	assert.NoError(t, err)
+	_, err = c.GetFreeIP(context.Background(), 1185)
+	assert.NoError(t, err)
+	fail = true
+	_, err = c.GetFreeIP(context.Background(), 1185)
+	assert.Error(t, err)
+
+	var rm metricdata.ResourceMetrics
+	assert.NoError(t, reader.Collect(context.Background(), &rm))
+
+	m, ok := findMetric(rm, "tidydns.client.operations")
+	assert.True(t, ok)
+	sum := m.Data.(metricdata.Sum[int64])
+	assert.Len(t, sum.DataPoints, 1)
+	assert.Equal(t, int64(2), sum.DataPoints[0].Value)
+	op, _ := sum.DataPoints[0].Attributes.Value(attrOperation)
+	assert.Equal(t, "GetFreeIP", op.AsString())
+
+	m, ok = findMetric(rm, "tidydns.client.operation.errors")
+	assert.True(t, ok)
+	sum = m.Data.(metricdata.Sum[int64])
+	assert.Len(t, sum.DataPoints, 1)
+	assert.Equal(t, int64(1), sum.DataPoints[0].Value)
+	errType, _ := sum.DataPoints[0].Attributes.Value(attrErrorType)
+	assert.Equal(t, "server_error", errType.AsString())
+
+	m, ok = findMetric(rm, "tidydns.client.operation.duration")
+	assert.True(t, ok)
+	hist := m.Data.(metricdata.Histogram[float64])
+	assert.Equal(t, uint64(2), hist.DataPoints[0].Count)
+
+	m, ok = findMetric(rm, "http.client.request.duration")
+	assert.True(t, ok)
+	hist = m.Data.(metricdata.Histogram[float64])
+	assert.Len(t, hist.DataPoints, 2)
+	for _, dp := range hist.DataPoints {
+		status, _ := dp.Attributes.Value(attrStatusCode)
+		assert.Contains(t, []int64{200, 503}, status.AsInt64())
+	}
+
+	_, ok = findMetric(rm, "tidydns.client.request.errors")
+	assert.True(t, ok)
+}
@@ -0,0 +1,90 @@
+// Package tidydnsprom exports TidyDNS client metrics to Prometheus.
+//
+// A Collector is both a prometheus.Collector and a tidydns.MetricsHook:
+//
+//	collector := tidydnsprom.NewCollector()
+//	prometheus.MustRegister(collector)
+//	client, err := tidydns.NewWithOptions(url, user, pass, tidydns.WithMetricsHook(collector))
+package tidydnsprom
+
+import (
+	"time"
+
+	"github.com/neticdk/tidydns-go/pkg/tidydns"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector records per-method request totals, error totals by error class,
+// in-flight calls and latency of TidyDNS client methods.
+type Collector struct {
+	requests *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	inFlight *prometheus.GaugeVec
+	duration *prometheus.HistogramVec
+}
+
+var _ tidydns.MetricsHook = (*Collector)(nil)
+var _ prometheus.Collector = (*Collector)(nil)
+
+// NewCollector creates a collector. It must be registered with a
+// prometheus.Registerer to be exported.
+func NewCollector() *Collector {
+	return &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "tidydns",
+			Subsystem: "client",
+			Name:      "requests_total",
+			Help:      "Total number of TidyDNS client method calls.",
+		}, []string{"method"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "tidydns",
+			Subsystem: "client",
+			Name:      "errors_total",
+			Help:      "Total number of TidyDNS client method calls that failed, by error class.",
+		}, []string{"method", "class"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "tidydns",
+			Subsystem: "client",
+			Name:      "in_flight_requests",
+			Help:      "Number of TidyDNS client method calls in progress.",
+		}, []string{"method"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "tidydns",
+			Subsystem: "client",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of TidyDNS client method calls.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.errors.Describe(ch)
+	c.inFlight.Describe(ch)
+	c.duration.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.requests.Collect(ch)
+	c.errors.Collect(ch)
+	c.inFlight.Collect(ch)
+	c.duration.Collect(ch)
+}
+
+// OperationStarted implements tidydns.MetricsHook.
+func (c *Collector) OperationStarted(operation string) {
+	c.inFlight.WithLabelValues(operation).Inc()
+}
+
+// OperationFinished implements tidydns.MetricsHook.
+func (c *Collector) OperationFinished(operation string, duration time.Duration, err error) {
+	c.inFlight.WithLabelValues(operation).Dec()
+	c.requests.WithLabelValues(operation).Inc()
+	c.duration.WithLabelValues(operation).Observe(duration.Seconds())
+	if err != nil {
+		c.errors.WithLabelValues(operation, tidydns.ErrorClass(err)).Inc()
+	}
+}
@@ -0,0 +1,57 @@
+package tidydnsprom
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/neticdk/tidydns-go/pkg/tidydns"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+const freeIPResponse = `{"status":0,"data":{"ip_address":"10.68.0.134"}}`
+
+func TestCollector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if strings.HasSuffix(req.URL.Path, "/404") {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = rw.Write([]byte(freeIPResponse))
+	}))
+	defer server.Close()
+
+	collector := NewCollector()
+	registry := prometheus.NewPedanticRegistry()
+	assert.NoError(t, registry.Register(collector))
+
+	c, err := tidydns.NewWithOptions(server.URL, "username", "password", tidydns.WithMetricsHook(collector))
+	assert.NoError(t, err)
+
+	_, err = c.GetFreeIP(context.Background(), 1185)
+	assert.NoError(t, err)
+	_, err = c.GetFreeIP(context.Background(), 404)
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+
+	expected := `
+# HELP tidydns_client_requests_total Total number of TidyDNS client method calls.
+# TYPE tidydns_client_requests_total counter
+tidydns_client_requests_total{method="GetFreeIP"} 2
+# HELP tidydns_client_errors_total Total number of TidyDNS client method calls that failed, by error class.
+# TYPE tidydns_client_errors_total counter
+tidydns_client_errors_total{class="not_found",method="GetFreeIP"} 1
+# HELP tidydns_client_in_flight_requests Number of TidyDNS client method calls in progress.
+# TYPE tidydns_client_in_flight_requests gauge
+tidydns_client_in_flight_requests{method="GetFreeIP"} 0
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"tidydns_client_requests_total",
+		"tidydns_client_errors_total",
+		"tidydns_client_in_flight_requests",
+	))
+	assert.Equal(t, 1, testutil.CollectAndCount(collector, "tidydns_client_request_duration_seconds"))
+}
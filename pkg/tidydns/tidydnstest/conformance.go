@@ -0,0 +1,697 @@
+package tidydnstest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/neticdk/tidydns-go/pkg/tidydns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ConformanceEnv is an implementation under test together with existing
+// data the conformance suite relies on.
+type ConformanceEnv struct {
+	Client tidydns.TidyDNSClient
+	// ZoneName is the name of an existing zone in which records may be
+	// created and deleted.
+	ZoneName string
+	// SubnetCIDR is an existing DHCP subnet in the zone with at least two
+	// free addresses.
+	SubnetCIDR string
+	// ReverseZoneName is the name of an existing reverse zone covering
+	// SubnetCIDR, in which PTR records may be created and deleted. The
+	// reverse zone tests are skipped if it is empty. EnsurePTR requires a
+	// client configured with tidydns.WithPTRRecordType.
+	ReverseZoneName string
+	// FreeNetworkCIDR is an unused network in which DHCP subnets may be
+	// created and deleted. The subnet lifecycle tests are skipped if it is
+	// empty.
+	FreeNetworkCIDR string
+}
+
+// ConformanceFactory returns a fresh environment for every test of the
+// conformance suite.
+type ConformanceFactory func(t *testing.T) ConformanceEnv
+
+// NewConformanceEnv seeds a fake with the data required by the conformance
+// suite and returns the environment for it.
+func NewConformanceEnv(f *Fake) ConformanceEnv {
+	zoneID := f.AddZone("conformance.example.com")
+	f.AddSubnet("192.0.2.0/28", zoneID, 100)
+	f.AddReverseZone("2.0.192.in-addr.arpa", "192.0.2.0/24")
+	return ConformanceEnv{
+		Client:          f,
+		ZoneName:        "conformance.example.com",
+		SubnetCIDR:      "192.0.2.0/28",
+		ReverseZoneName: "2.0.192.in-addr.arpa",
+		FreeNetworkCIDR: "203.0.113.0/24",
+	}
+}
+
+// RunConformance verifies that an implementation of tidydns.TidyDNSClient
+// behaves like the real client, covering both successful calls and the
+// errors returned for missing or conflicting data. Objects created by the
+// suite are given unique names and removed again, so it can also be run
+// against a live TidyDNS installation.
+func RunConformance(t *testing.T, factory ConformanceFactory) {
+	t.Run("Zones", func(t *testing.T) { testZones(t, factory(t)) })
+	t.Run("ZoneLifecycle", func(t *testing.T) { testZoneLifecycle(t, factory(t)) })
+	t.Run("ZoneServers", func(t *testing.T) { testZoneServers(t, factory(t)) })
+	t.Run("ZoneAliases", func(t *testing.T) { testZoneAliases(t, factory(t)) })
+	t.Run("DNSSEC", func(t *testing.T) { testDNSSEC(t, factory(t)) })
+	t.Run("ReverseZones", func(t *testing.T) { testReverseZones(t, factory(t)) })
+	t.Run("Records", func(t *testing.T) { testRecords(t, factory(t)) })
+	t.Run("Subnets", func(t *testing.T) { testSubnets(t, factory(t)) })
+	t.Run("SubnetLifecycle", func(t *testing.T) { testSubnetLifecycle(t, factory(t)) })
+	t.Run("SubnetUsage", func(t *testing.T) { testSubnetUsage(t, factory(t)) })
+	t.Run("DHCPInterfaces", func(t *testing.T) { testDHCPInterfaces(t, factory(t)) })
+	t.Run("AllocateInterface", func(t *testing.T) { testAllocateInterface(t, factory(t)) })
+	t.Run("InternalUsers", func(t *testing.T) { testInternalUsers(t, factory(t)) })
+}
+
+func testZones(t *testing.T, env ConformanceEnv) {
+	ctx := context.Background()
+	c := env.Client
+
+	zones, err := c.ListZones(ctx)
+	require.NoError(t, err)
+	var zoneID int
+	for _, z := range zones {
+		if z.Name == env.ZoneName {
+			zoneID = z.ID
+		}
+	}
+	require.NotZero(t, zoneID, "zone %s not listed", env.ZoneName)
+
+	id, err := c.FindZoneID(ctx, env.ZoneName)
+	require.NoError(t, err)
+	assert.Equal(t, zoneID, id)
+
+	_, err = c.FindZoneID(ctx, uniqueName("missing")+".invalid")
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+
+	zone, err := c.GetZone(ctx, zoneID)
+	require.NoError(t, err)
+	assert.Equal(t, zoneID, zone.ID)
+	assert.Equal(t, env.ZoneName, zone.Name)
+	assert.NotZero(t, zone.Serial)
+
+	detailed, err := c.ListZonesDetailed(ctx)
+	require.NoError(t, err)
+	assert.Len(t, detailed, len(zones))
+	for _, z := range detailed {
+		if z.ID == zoneID {
+			assert.Equal(t, zone.Serial, z.Serial)
+		}
+	}
+}
+
+func testZoneLifecycle(t *testing.T, env ConformanceEnv) {
+	ctx := context.Background()
+	c := env.Client
+
+	name := uniqueName("zone") + "." + env.ZoneName
+	description := "conformance test"
+	zoneID, err := c.CreateZone(ctx, name, tidydns.ZoneSettings{Description: &description})
+	require.NoError(t, err)
+	require.NotZero(t, zoneID)
+	t.Cleanup(func() { _ = c.DeleteZone(context.Background(), zoneID) })
+
+	_, err = c.CreateZone(ctx, name, tidydns.ZoneSettings{})
+	assert.ErrorIs(t, err, tidydns.ErrAlreadyExists)
+
+	_, err = c.CreateZone(ctx, uniqueName("zone")+"."+env.ZoneName, tidydns.ZoneSettings{Masters: []string{"not an address"}})
+	assert.ErrorIs(t, err, tidydns.ErrInvalidArgument)
+
+	id, err := c.FindZoneID(ctx, name)
+	require.NoError(t, err)
+	assert.Equal(t, zoneID, id)
+
+	zone, err := c.GetZone(ctx, zoneID)
+	require.NoError(t, err)
+	assert.Equal(t, name, zone.Name)
+	assert.Equal(t, description, zone.Description)
+
+	ttl := 2 * time.Hour
+	allowTransfer := []string{"192.0.2.1"}
+	require.NoError(t, c.UpdateZone(ctx, zoneID, tidydns.ZoneSettings{SOATTL: &ttl, AllowTransfer: allowTransfer}))
+	zone, err = c.WaitForProvisioned(ctx, zoneID, tidydns.WaitOptions{
+		Serial:   zone.Serial,
+		Interval: time.Second,
+		Timeout:  5 * time.Minute,
+	})
+	require.NoError(t, err)
+	assert.True(t, zone.Provisioned())
+	assert.Equal(t, ttl, zone.SOA.TTL)
+	assert.Equal(t, allowTransfer, zone.AllowTransfer)
+	assert.Equal(t, description, zone.Description)
+
+	require.NoError(t, c.DeleteZone(ctx, zoneID))
+	_, err = c.GetZone(ctx, zoneID)
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+	assert.ErrorIs(t, c.DeleteZone(ctx, zoneID), tidydns.ErrNotFound)
+}
+
+func testZoneServers(t *testing.T, env ConformanceEnv) {
+	ctx := context.Background()
+	c := env.Client
+
+	secondary := tidydns.ZoneServerConfig{
+		Masters:       []string{"192.0.2.53", "2001:db8::53"},
+		AllowTransfer: []string{"192.0.2.0/24"},
+	}
+	settings := secondary.Settings()
+	zoneID, err := c.CreateZone(ctx, uniqueName("secondary")+"."+env.ZoneName, settings)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.DeleteZone(context.Background(), zoneID) })
+
+	config, err := c.GetZoneServerConfig(ctx, zoneID)
+	require.NoError(t, err)
+	assert.Equal(t, &secondary, config)
+
+	forward := tidydns.ZoneServerConfig{
+		Forwarders: []string{"192.0.2.54"},
+	}
+	require.NoError(t, c.UpdateZoneServerConfig(ctx, zoneID, forward))
+	config, err = c.GetZoneServerConfig(ctx, zoneID)
+	require.NoError(t, err)
+	assert.Equal(t, &forward, config)
+
+	err = c.UpdateZoneServerConfig(ctx, zoneID, tidydns.ZoneServerConfig{Masters: []string{"ns1.example.com"}})
+	assert.ErrorIs(t, err, tidydns.ErrInvalidArgument)
+
+	_, err = c.GetZoneServerConfig(ctx, zoneID+1000000)
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+}
+
+func testZoneAliases(t *testing.T, env ConformanceEnv) {
+	ctx := context.Background()
+	c := env.Client
+
+	targetName := uniqueName("target") + "." + env.ZoneName
+	targetID, err := c.CreateZone(ctx, targetName, tidydns.ZoneSettings{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.DeleteZone(context.Background(), targetID) })
+
+	aliasName := uniqueName("alias") + "." + env.ZoneName
+	aliasID, err := c.CreateZoneAlias(ctx, aliasName, targetID)
+	require.NoError(t, err)
+	require.NotZero(t, aliasID)
+	t.Cleanup(func() { _ = c.DeleteZone(context.Background(), aliasID) })
+
+	id, err := c.FindZoneID(ctx, aliasName)
+	require.NoError(t, err)
+	assert.Equal(t, targetID, id)
+
+	alias, err := c.GetZone(ctx, aliasID)
+	require.NoError(t, err)
+	assert.Equal(t, tidydns.ZoneTypeAlias, alias.Type)
+	assert.Equal(t, targetID, alias.AliasID)
+
+	aliases, err := c.ListZoneAliases(ctx, targetID)
+	require.NoError(t, err)
+	require.Len(t, aliases, 1)
+	assert.Equal(t, aliasID, aliases[0].ID)
+
+	zones, err := c.ListZones(ctx)
+	require.NoError(t, err)
+	for _, z := range zones {
+		switch z.ID {
+		case aliasID:
+			assert.Equal(t, targetID, z.AliasID)
+		case targetID:
+			assert.Zero(t, z.AliasID)
+		}
+	}
+
+	assert.ErrorIs(t, c.DeleteZoneAlias(ctx, targetID), tidydns.ErrInvalidArgument)
+	require.NoError(t, c.DeleteZoneAlias(ctx, aliasID))
+	aliases, err = c.ListZoneAliases(ctx, targetID)
+	require.NoError(t, err)
+	assert.Empty(t, aliases)
+	assert.ErrorIs(t, c.DeleteZoneAlias(ctx, aliasID), tidydns.ErrNotFound)
+}
+
+func testDNSSEC(t *testing.T, env ConformanceEnv) {
+	ctx := context.Background()
+	c := env.Client
+
+	zoneID, err := c.CreateZone(ctx, uniqueName("signed")+"."+env.ZoneName, tidydns.ZoneSettings{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.DeleteZone(context.Background(), zoneID) })
+
+	zone, err := c.GetZone(ctx, zoneID)
+	require.NoError(t, err)
+	require.NoError(t, c.SetDNSSEC(ctx, zoneID, true))
+	_, err = c.WaitForProvisioned(ctx, zoneID, tidydns.WaitOptions{
+		Serial:   zone.Serial,
+		Interval: time.Second,
+		Timeout:  5 * time.Minute,
+	})
+	require.NoError(t, err)
+
+	status, err := c.GetDNSSECStatus(ctx, zoneID)
+	require.NoError(t, err)
+	assert.True(t, status.Enabled)
+
+	require.NoError(t, c.GenerateDNSSECKeys(ctx, zoneID))
+	require.NoError(t, c.SetDNSSEC(ctx, zoneID, false))
+	status, err = c.GetDNSSECStatus(ctx, zoneID)
+	require.NoError(t, err)
+	assert.False(t, status.Enabled)
+
+	_, err = c.GetDNSSECStatus(ctx, zoneID+1000000)
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+}
+
+func testReverseZones(t *testing.T, env ConformanceEnv) {
+	if env.ReverseZoneName == "" {
+		t.Skip("no reverse zone configured")
+	}
+	ctx := context.Background()
+	c := env.Client
+
+	// Use the last address of the subnet, which is not handed out by
+	// GetFreeIP while the subnet has other free addresses.
+	prefix := netip.MustParsePrefix(env.SubnetCIDR).Masked()
+	addr := prefix.Addr()
+	for next := addr.Next(); prefix.Contains(next.Next()); next = next.Next() {
+		addr = next
+	}
+
+	zone, err := c.FindReverseZoneForIP(ctx, addr)
+	require.NoError(t, err)
+	assert.Equal(t, env.ReverseZoneName, zone.Name)
+
+	_, err = c.FindReverseZoneForIP(ctx, netip.MustParseAddr("2001:db8::1"))
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+
+	name, err := zone.PTRName(addr)
+	require.NoError(t, err)
+	existing, err := findPTR(ctx, c, zone.ID, name)
+	require.NoError(t, err)
+	if len(existing) > 0 {
+		t.Skipf("PTR record for %s already exists", addr)
+	}
+
+	host := uniqueName("ptr") + "." + env.ZoneName
+	recordID, err := c.EnsurePTR(ctx, addr, host)
+	require.NoError(t, err)
+	require.NotZero(t, recordID)
+	t.Cleanup(func() { _ = c.DeleteRecord(context.Background(), zone.ID, recordID) })
+
+	record, err := c.ReadRecord(ctx, zone.ID, recordID)
+	require.NoError(t, err)
+	assert.Equal(t, tidydns.RecordTypeUnknown, record.Type)
+	assert.Equal(t, "PTR", record.TypeName)
+	assert.Equal(t, name, record.Name)
+	assert.Equal(t, host+".", record.Destination)
+
+	host = uniqueName("ptr") + "." + env.ZoneName + "."
+	id, err := c.EnsurePTR(ctx, addr, host)
+	require.NoError(t, err)
+	assert.Equal(t, recordID, id)
+	found, err := findPTR(ctx, c, zone.ID, name)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, host, found[0].Destination)
+
+	_, err = c.EnsurePTR(ctx, addr, "")
+	assert.ErrorIs(t, err, tidydns.ErrInvalidArgument)
+}
+
+// findPTR returns the PTR records with the given name.
+func findPTR(ctx context.Context, c tidydns.TidyDNSClient, zoneID int, name string) ([]*tidydns.RecordInfo, error) {
+	records, err := c.FindRecord(ctx, zoneID, name, tidydns.RecordTypeUnknown)
+	if err != nil {
+		return nil, err
+	}
+	var result []*tidydns.RecordInfo
+	for _, r := range records {
+		if isPTR(*r) {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
+func testRecords(t *testing.T, env ConformanceEnv) {
+	ctx := context.Background()
+	c := env.Client
+
+	zoneID, err := c.FindZoneID(ctx, env.ZoneName)
+	require.NoError(t, err)
+
+	name := uniqueName("record")
+	info := tidydns.RecordInfo{
+		Type:        tidydns.RecordTypeTXT,
+		Name:        name,
+		Description: "conformance test",
+		Destination: "first",
+		TTL:         300,
+		Status:      tidydns.RecordStatusActive,
+	}
+	recordID, err := c.CreateRecord(ctx, zoneID, info)
+	require.NoError(t, err)
+	require.NotZero(t, recordID)
+	t.Cleanup(func() { _ = c.DeleteRecord(context.Background(), zoneID, recordID) })
+
+	record, err := c.ReadRecord(ctx, zoneID, recordID)
+	require.NoError(t, err)
+	assert.Equal(t, recordID, record.ID)
+	assert.Equal(t, tidydns.RecordTypeTXT, record.Type)
+	assert.Equal(t, name, record.Name)
+	assert.Equal(t, "first", record.Destination)
+	assert.Equal(t, 300, record.TTL)
+
+	found, err := c.FindRecord(ctx, zoneID, name, tidydns.RecordTypeTXT)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, recordID, found[0].ID)
+
+	found, err = c.FindRecord(ctx, zoneID, name, tidydns.RecordTypeA)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+
+	info.Destination = "second"
+	info.TTL = 600
+	require.NoError(t, c.UpdateRecord(ctx, zoneID, recordID, info))
+	record, err = c.ReadRecord(ctx, zoneID, recordID)
+	require.NoError(t, err)
+	assert.Equal(t, "second", record.Destination)
+	assert.Equal(t, 600, record.TTL)
+	assert.Equal(t, name, record.Name)
+
+	records, err := c.ListRecords(ctx, zoneID)
+	require.NoError(t, err)
+	listed := false
+	for _, r := range records {
+		if r.ID == recordID {
+			listed = true
+			assert.Equal(t, name, r.Name)
+			assert.Equal(t, "second", r.Destination)
+		}
+	}
+	assert.True(t, listed, "record %d not listed", recordID)
+
+	require.NoError(t, c.DeleteRecord(ctx, zoneID, recordID))
+	_, err = c.ReadRecord(ctx, zoneID, recordID)
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+	assert.ErrorIs(t, c.DeleteRecord(ctx, zoneID, recordID), tidydns.ErrNotFound)
+}
+
+func testSubnets(t *testing.T, env ConformanceEnv) {
+	ctx := context.Background()
+	c := env.Client
+
+	ids, err := c.GetSubnetIDs(ctx, env.SubnetCIDR)
+	require.NoError(t, err)
+
+	subnet, err := c.GetSubnet(ctx, ids.SubnetID)
+	require.NoError(t, err)
+	assert.Equal(t, env.SubnetCIDR, subnet.Prefix.String())
+	assert.Equal(t, ids.ZoneID, subnet.ZoneID)
+	assert.Equal(t, ids.VlanNo, subnet.VlanNo)
+	family, otherFamily := 4, 6
+	if subnet.Prefix.Addr().Is6() {
+		family, otherFamily = 6, 4
+	}
+	assert.Equal(t, family, subnet.Family)
+
+	subnets, err := c.ListSubnets(ctx, tidydns.SubnetFilter{})
+	require.NoError(t, err)
+	assert.Contains(t, subnets, subnet)
+
+	subnets, err = c.ListSubnets(ctx, tidydns.SubnetFilter{VlanNo: subnet.VlanNo, Family: family, CustomerID: &subnet.CustomerID})
+	require.NoError(t, err)
+	assert.Contains(t, subnets, subnet)
+
+	subnets, err = c.ListSubnets(ctx, tidydns.SubnetFilter{Family: otherFamily})
+	require.NoError(t, err)
+	assert.NotContains(t, subnets, subnet)
+
+	byPrefix, err := c.GetSubnetByPrefix(ctx, subnet.Prefix)
+	require.NoError(t, err)
+	assert.Equal(t, subnet, byPrefix)
+
+	found, err := c.FindSubnetForIP(ctx, subnet.Prefix.Addr().Next())
+	require.NoError(t, err)
+	assert.Equal(t, subnet.ID, found.ID)
+
+	_, err = c.GetSubnet(ctx, ids.SubnetID+1000000)
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+	_, err = c.GetSubnetByPrefix(ctx, netip.MustParsePrefix("198.51.100.0/24"))
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+	_, err = c.FindSubnetForIP(ctx, netip.MustParseAddr("198.51.100.1"))
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+}
+
+func testSubnetLifecycle(t *testing.T, env ConformanceEnv) {
+	if env.FreeNetworkCIDR == "" {
+		t.Skip("no free network configured")
+	}
+	ctx := context.Background()
+	c := env.Client
+
+	ids, err := c.GetSubnetIDs(ctx, env.SubnetCIDR)
+	require.NoError(t, err)
+	network := netip.MustParsePrefix(env.FreeNetworkCIDR)
+	// The first network of 64 addresses, e.g. a /26 for IPv4.
+	prefix := netip.PrefixFrom(network.Addr(), network.Addr().BitLen()-6)
+	if prefix.Bits() < network.Bits() {
+		prefix = network
+	}
+
+	name := uniqueName("subnet")
+	dhcpActive := false
+	subnetID, err := c.CreateSubnet(ctx, prefix, tidydns.SubnetSettings{
+		Name:       &name,
+		ZoneID:     &ids.ZoneID,
+		DHCPActive: &dhcpActive,
+	})
+	require.NoError(t, err)
+	require.NotZero(t, subnetID)
+	t.Cleanup(func() { _ = c.DeleteSubnet(context.Background(), subnetID) })
+
+	subnet, err := c.GetSubnet(ctx, subnetID)
+	require.NoError(t, err)
+	assert.Equal(t, prefix, subnet.Prefix)
+	assert.Equal(t, name, subnet.Name)
+	assert.Equal(t, ids.ZoneID, subnet.ZoneID)
+	assert.False(t, subnet.DHCPActive)
+
+	_, err = c.CreateSubnet(ctx, prefix, tidydns.SubnetSettings{Name: &name})
+	assert.ErrorIs(t, err, tidydns.ErrAlreadyExists)
+	_, err = c.CreateSubnet(ctx, network, tidydns.SubnetSettings{Name: &name})
+	assert.ErrorIs(t, err, tidydns.ErrConflict)
+	var overlap *tidydns.SubnetOverlapError
+	if assert.ErrorAs(t, err, &overlap) {
+		assert.Equal(t, subnetID, overlap.Subnet.ID)
+	}
+	_, err = c.CreateSubnet(ctx, netip.PrefixFrom(prefix.Addr().Next(), prefix.Bits()), tidydns.SubnetSettings{})
+	assert.ErrorIs(t, err, tidydns.ErrInvalidArgument)
+
+	description := "conformance test"
+	failover := true
+	require.NoError(t, c.UpdateSubnet(ctx, subnetID, tidydns.SubnetSettings{Description: &description, DHCPFailover: &failover}))
+	subnet, err = c.GetSubnet(ctx, subnetID)
+	require.NoError(t, err)
+	assert.Equal(t, description, subnet.Description)
+	assert.True(t, subnet.DHCPFailover)
+	assert.Equal(t, name, subnet.Name)
+
+	require.NoError(t, c.DeleteSubnet(ctx, subnetID))
+	_, err = c.GetSubnet(ctx, subnetID)
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+	assert.ErrorIs(t, c.DeleteSubnet(ctx, subnetID), tidydns.ErrNotFound)
+}
+
+func testSubnetUsage(t *testing.T, env ConformanceEnv) {
+	ctx := context.Background()
+	c := env.Client
+
+	ids, err := c.GetSubnetIDs(ctx, env.SubnetCIDR)
+	require.NoError(t, err)
+	before, err := c.SubnetUsage(ctx, ids.SubnetID)
+	require.NoError(t, err)
+	assert.Equal(t, env.SubnetCIDR, before.Prefix.String())
+	assert.Equal(t, before.Total, before.Used+before.Free+before.Reserved)
+	require.NotEmpty(t, before.FreeRanges)
+
+	ip, err := c.GetFreeIP(ctx, ids.SubnetID)
+	require.NoError(t, err)
+	id, err := c.CreateDHCPInterface(ctx, tidydns.CreateInfo{
+		SubnetID:      ids.SubnetID,
+		ZoneID:        ids.ZoneID,
+		InterfaceIP:   ip,
+		InterfaceName: uniqueName("iface"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.DeleteDHCPInterface(context.Background(), id) })
+
+	after, err := c.SubnetUsage(ctx, ids.SubnetID)
+	require.NoError(t, err)
+	assert.Equal(t, before.Used+1, after.Used)
+	assert.Equal(t, before.Free-1, after.Free)
+	addr := netip.MustParseAddr(ip)
+	for _, r := range after.FreeRanges {
+		assert.False(t, !addr.Less(r.First) && !r.Last.Less(addr), "%s in free range %s-%s", addr, r.First, r.Last)
+	}
+
+	usages, err := c.ListSubnetUsage(ctx, tidydns.SubnetFilter{})
+	require.NoError(t, err)
+	var found bool
+	for _, u := range usages {
+		if u.SubnetID == ids.SubnetID {
+			found = true
+			assert.Equal(t, after, u)
+		}
+	}
+	assert.True(t, found)
+
+	_, err = c.SubnetUsage(ctx, ids.SubnetID+1000000)
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+}
+
+func testDHCPInterfaces(t *testing.T, env ConformanceEnv) {
+	ctx := context.Background()
+	c := env.Client
+
+	ids, err := c.GetSubnetIDs(ctx, env.SubnetCIDR)
+	require.NoError(t, err)
+	require.NotZero(t, ids.SubnetID)
+
+	_, err = c.GetSubnetIDs(ctx, "198.51.100.0/24")
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+
+	ip, err := c.GetFreeIP(ctx, ids.SubnetID)
+	require.NoError(t, err)
+	require.NotEmpty(t, ip)
+
+	createInfo := tidydns.CreateInfo{
+		SubnetID:      ids.SubnetID,
+		ZoneID:        ids.ZoneID,
+		InterfaceIP:   ip,
+		InterfaceName: uniqueName("iface"),
+	}
+	id, err := c.CreateDHCPInterface(ctx, createInfo)
+	require.NoError(t, err)
+	require.NotZero(t, id)
+	t.Cleanup(func() { _ = c.DeleteDHCPInterface(context.Background(), id) })
+
+	dupID, err := c.CreateDHCPInterface(ctx, createInfo)
+	assert.ErrorIs(t, err, tidydns.ErrAlreadyExists)
+	assert.Zero(t, dupID)
+
+	next, err := c.GetFreeIP(ctx, ids.SubnetID)
+	require.NoError(t, err)
+	assert.NotEqual(t, ip, next)
+
+	info, err := c.ReadDHCPInterface(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, id, info.ID)
+	assert.Equal(t, ip, info.InterfaceIP)
+	assert.Equal(t, createInfo.InterfaceName, info.Interfacename)
+
+	newName := uniqueName("iface")
+	updatedID, err := c.UpdateDHCPInterfaceName(ctx, id, newName)
+	require.NoError(t, err)
+	assert.Equal(t, id, updatedID)
+
+	interfaces, err := c.ListDHCPInterfaces(ctx, ids.SubnetID)
+	require.NoError(t, err)
+	listed := false
+	for _, i := range interfaces {
+		if i.ID == id {
+			listed = true
+			assert.Equal(t, newName, i.Interfacename)
+			assert.Equal(t, ip, i.InterfaceIP)
+		}
+	}
+	assert.True(t, listed, "interface %d not listed", id)
+
+	require.NoError(t, c.DeleteDHCPInterface(ctx, id))
+	_, err = c.ReadDHCPInterface(ctx, id)
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+	assert.ErrorIs(t, c.DeleteDHCPInterface(ctx, id), tidydns.ErrNotFound)
+}
+
+func testAllocateInterface(t *testing.T, env ConformanceEnv) {
+	ctx := context.Background()
+	c := env.Client
+
+	ids, err := c.GetSubnetIDs(ctx, env.SubnetCIDR)
+	require.NoError(t, err)
+	ip, err := c.GetFreeIP(ctx, ids.SubnetID)
+	require.NoError(t, err)
+	excluded := netip.MustParseAddr(ip)
+
+	name := uniqueName("iface")
+	info, err := c.AllocateInterface(ctx, ids.SubnetID, name, tidydns.AllocateOptions{Exclude: []netip.Addr{excluded}})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.DeleteDHCPInterface(context.Background(), info.ID) })
+	assert.Equal(t, name, info.Interfacename)
+	assert.NotEqual(t, ip, info.InterfaceIP)
+	assert.True(t, netip.MustParsePrefix(env.SubnetCIDR).Contains(netip.MustParseAddr(info.InterfaceIP)))
+
+	read, err := c.ReadDHCPInterface(ctx, info.ID)
+	require.NoError(t, err)
+	assert.Equal(t, info, read)
+
+	other, err := c.AllocateInterface(ctx, ids.SubnetID, uniqueName("iface"), tidydns.AllocateOptions{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.DeleteDHCPInterface(context.Background(), other.ID) })
+	assert.NotEqual(t, info.InterfaceIP, other.InterfaceIP)
+
+	_, err = c.AllocateInterface(ctx, ids.SubnetID, "", tidydns.AllocateOptions{})
+	assert.ErrorIs(t, err, tidydns.ErrInvalidArgument)
+	_, err = c.AllocateInterface(ctx, ids.SubnetID+1000000, uniqueName("iface"), tidydns.AllocateOptions{})
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+}
+
+func testInternalUsers(t *testing.T, env ConformanceEnv) {
+	ctx := context.Background()
+	c := env.Client
+
+	username := uniqueName("user")
+	id, err := c.CreateInternalUser(ctx, username, "Secret-1234", "conformance test", false, tidydns.AuthGroupUser, nil)
+	require.NoError(t, err)
+	require.NotZero(t, id)
+	t.Cleanup(func() { _ = c.DeleteInternalUser(context.Background(), id) })
+
+	_, err = c.CreateInternalUser(ctx, username, "Secret-1234", "conformance test", false, tidydns.AuthGroupUser, nil)
+	assert.ErrorIs(t, err, tidydns.ErrAlreadyExists)
+
+	_, err = c.CreateInternalUser(ctx, uniqueName("user"), "Secret-1234", "", false, tidydns.AuthGroup(999), nil)
+	assert.Error(t, err)
+
+	user, err := c.GetInternalUser(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, id, user.Id)
+	assert.Equal(t, username, user.Username)
+	assert.Equal(t, "conformance test", user.Description)
+	assert.Equal(t, tidydns.AuthGroupUser, user.AuthGroup)
+
+	description := "updated"
+	superAdmin := tidydns.AuthGroupSuperAdmin
+	require.NoError(t, c.UpdateInternalUser(ctx, id, nil, &description, &superAdmin, nil))
+	user, err = c.GetInternalUser(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "updated", user.Description)
+	assert.Equal(t, tidydns.AuthGroupSuperAdmin, user.AuthGroup)
+
+	require.NoError(t, c.DeleteInternalUser(ctx, id))
+	_, err = c.GetInternalUser(ctx, id)
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+}
+
+func uniqueName(prefix string) string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return "conformance-" + prefix + "-" + hex.EncodeToString(b)
+}
@@ -0,0 +1,22 @@
+package tidydnstest
+
+import (
+	"testing"
+)
+
+func TestConformanceFake(t *testing.T) {
+	RunConformance(t, func(t *testing.T) ConformanceEnv {
+		return NewConformanceEnv(NewFake())
+	})
+}
+
+func TestConformanceServer(t *testing.T) {
+	RunConformance(t, func(t *testing.T) ConformanceEnv {
+		s := NewServer()
+		t.Cleanup(s.Close)
+
+		env := NewConformanceEnv(s.State())
+		env.Client = s.Client()
+		return env
+	})
+}
@@ -0,0 +1,1625 @@
+// Package tidydnstest provides test doubles for code that depends on
+// tidydns.TidyDNSClient.
+package tidydnstest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/neticdk/tidydns-go/pkg/tidydns"
+)
+
+// Fake is a stateful in-memory implementation of tidydns.TidyDNSClient.
+// It mimics the behaviour of TidyDNS closely enough for unit tests: IDs are
+// assigned on creation, unknown IDs result in errors matching
+// tidydns.ErrNotFound and duplicate interface addresses or usernames result
+// in errors matching tidydns.ErrAlreadyExists. It is safe for concurrent use.
+type Fake struct {
+	mu         sync.Mutex
+	nextID     int
+	zones      map[int]*tidydns.Zone
+	records    map[int]*fakeRecord
+	subnets    map[int]*tidydns.Subnet
+	interfaces map[int]*fakeInterface
+	users      map[tidydns.UserID]*fakeUser
+	errors     map[string]error
+}
+
+var _ tidydns.TidyDNSClient = (*Fake)(nil)
+
+type fakeRecord struct {
+	zoneID int
+	info   tidydns.RecordInfo
+	// fields holds the value, weight and port fields set through the
+	// HTTP emulator.
+	fields map[string]int
+}
+
+// unsignedParentState is the DNSSEC parent state TidyDNS reports for the
+// unsigned zones in recorded responses.
+const unsignedParentState = -1
+
+// FakePTRRecordType is the number the Fake and the Server use for PTR
+// records. It is made up for tests and is not the number TidyDNS uses:
+// tidydns has no constant for PTR records, so clients configure the number
+// of their TidyDNS installation with tidydns.WithPTRRecordType. Like
+// TidyDNS, the Fake reports PTR records with the type name "PTR", so the
+// client reads them as tidydns.RecordTypeUnknown.
+const FakePTRRecordType tidydns.RecordType = 100
+
+// knownType reports whether tidydns has a constant for a record type.
+func knownType(t tidydns.RecordType) bool {
+	_, err := tidydns.ParseRecordType(t.String())
+	return err == nil
+}
+
+// storeType converts the type of a record being created to the form
+// records are read in, and reports whether the type is known.
+func storeType(info *tidydns.RecordInfo) bool {
+	if info.Type == FakePTRRecordType {
+		info.Type, info.TypeName = tidydns.RecordTypeUnknown, "PTR"
+		return true
+	}
+	return knownType(info.Type)
+}
+
+// typeID returns the number the Fake reports for the type of a record.
+func typeID(info tidydns.RecordInfo) tidydns.RecordType {
+	if isPTR(info) {
+		return FakePTRRecordType
+	}
+	return info.Type
+}
+
+// hasType reports whether a record is of the given type the way the client
+// matches types: types tidydns has no constant for are compared by number,
+// while tidydns.RecordTypeUnknown matches every record of such a type.
+func hasType(info tidydns.RecordInfo, t tidydns.RecordType) bool {
+	if knownType(t) || t == tidydns.RecordTypeUnknown {
+		return info.Type == t
+	}
+	return typeID(info) == t
+}
+
+func isPTR(info tidydns.RecordInfo) bool {
+	return info.Type == tidydns.RecordTypeUnknown && strings.EqualFold(info.TypeName, "PTR")
+}
+
+// setData stores record data the way TidyDNS does, in the destination and
+// the value, weight and port fields.
+func (r *fakeRecord) setData(data tidydns.RecordData) {
+	r.info.Data = data
+	if data == nil {
+		return
+	}
+	fields := map[string]int{}
+	switch d := data.(type) {
+	case tidydns.MXData:
+		r.info.Destination = d.Exchange
+		fields["value"] = int(d.Preference)
+	case tidydns.SRVData:
+		r.info.Destination = d.Target
+		fields["value"] = int(d.Priority)
+		fields["weight"] = int(d.Weight)
+		fields["port"] = int(d.Port)
+	case tidydns.CAAData:
+		r.info.Destination = d.Tag + " " + `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(d.Value) + `"`
+		fields["value"] = int(d.Flags)
+	case tidydns.TLSAData:
+		r.info.Destination = fmt.Sprintf("%d %d %d %s", d.Usage, d.Selector, d.MatchingType, d.Certificate)
+	case tidydns.SSHFPData:
+		r.info.Destination = fmt.Sprintf("%d %d %s", d.Algorithm, d.Type, d.Fingerprint)
+	case tidydns.DSData:
+		r.info.Destination = fmt.Sprintf("%d %d %d %s", d.KeyTag, d.Algorithm, d.DigestType, d.Digest)
+	}
+	r.fields = fields
+}
+
+type fakeInterface struct {
+	subnetID   int
+	zoneID     int
+	locationID int
+	info       tidydns.InterfaceInfo
+}
+
+type fakeUser struct {
+	info      tidydns.UserInfo
+	password  string
+	userAllow []tidydns.UserAllowID
+}
+
+// NewFake creates an empty fake.
+func NewFake() *Fake {
+	return &Fake{
+		nextID:     1000,
+		zones:      map[int]*tidydns.Zone{},
+		records:    map[int]*fakeRecord{},
+		subnets:    map[int]*tidydns.Subnet{},
+		interfaces: map[int]*fakeInterface{},
+		users:      map[tidydns.UserID]*fakeUser{},
+		errors:     map[string]error{},
+	}
+}
+
+// AddZone seeds a zone and returns its ID.
+func (f *Fake) AddZone(name string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id := f.newID()
+	now := time.Now().UTC().Truncate(time.Second)
+	f.zones[id] = &tidydns.Zone{
+		ID:            id,
+		Name:          name,
+		Type:          tidydns.ZoneTypeRegular,
+		TypeText:      tidydns.ZoneTypeRegular.String(),
+		Serial:        1,
+		InjectNS:      true,
+		ProvisionDate: now,
+		CreatedDate:   now,
+		ModifiedDate:  now,
+		DNSSEC:        tidydns.DNSSEC{ParentState: unsignedParentState},
+	}
+	return id
+}
+
+// AddReverseZone seeds a reverse zone covering network and returns its ID.
+// It panics if network is not a valid prefix.
+func (f *Fake) AddReverseZone(name string, network string) int {
+	prefix := netip.MustParsePrefix(network).Masked()
+	id := f.AddZone(name)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.zones[id].Network = prefix.String()
+	return id
+}
+
+// SetZone replaces the configuration and state of an existing zone, e.g. to
+// simulate provisioning. It panics if the zone does not exist.
+func (f *Fake) SetZone(zone tidydns.Zone) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.zones[zone.ID]; !ok {
+		panic(fmt.Sprintf("tidydnstest: unknown zone %d", zone.ID))
+	}
+	f.zones[zone.ID] = cloneZone(&zone)
+}
+
+// AddSubnet seeds a DHCP subnet in the given zone and returns its ID. It
+// panics if cidr is not a valid prefix.
+func (f *Fake) AddSubnet(cidr string, zoneID int, vlanNo int) int {
+	prefix := netip.MustParsePrefix(cidr).Masked()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	family := 6
+	if prefix.Addr().Is4() {
+		family = 4
+	}
+	id := f.newID()
+	now := time.Now().UTC().Truncate(time.Second)
+	f.subnets[id] = &tidydns.Subnet{
+		ID:           id,
+		Prefix:       prefix,
+		Family:       family,
+		VlanNo:       vlanNo,
+		ZoneID:       zoneID,
+		CreatedDate:  now,
+		ModifiedDate: now,
+	}
+	return id
+}
+
+// SetSubnet replaces the configuration of an existing subnet, e.g. to set
+// its VLAN or location. It panics if the subnet does not exist.
+func (f *Fake) SetSubnet(subnet tidydns.Subnet) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.subnets[subnet.ID]; !ok {
+		panic(fmt.Sprintf("tidydnstest: unknown subnet %d", subnet.ID))
+	}
+	f.subnets[subnet.ID] = &subnet
+}
+
+// AddRecord seeds a record in the given zone and returns its ID. PTR
+// records are added with type FakePTRRecordType.
+func (f *Fake) AddRecord(zoneID int, info tidydns.RecordInfo) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	storeType(&info)
+	info.ID = f.newID()
+	record := &fakeRecord{zoneID: zoneID, info: info}
+	record.setData(info.Data)
+	f.records[info.ID] = record
+	return info.ID
+}
+
+// SetError makes every call of the named client method, e.g. "GetFreeIP",
+// return err. Passing a nil error clears it again.
+func (f *Fake) SetError(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err == nil {
+		delete(f.errors, method)
+		return
+	}
+	f.errors[method] = err
+}
+
+// Zones returns all zones sorted by ID.
+func (f *Fake) Zones() []tidydns.ZoneInfo {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	result := make([]tidydns.ZoneInfo, 0, len(f.zones))
+	for _, z := range f.zones {
+		result = append(result, *zoneInfo(z))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// Records returns the records of a zone sorted by ID.
+func (f *Fake) Records(zoneID int) []tidydns.RecordInfo {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.zoneRecords(zoneID)
+}
+
+// Interfaces returns the DHCP interfaces of a subnet sorted by ID.
+func (f *Fake) Interfaces(subnetID int) []tidydns.InterfaceInfo {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	result := make([]tidydns.InterfaceInfo, 0)
+	for _, i := range f.subnetInterfaces(subnetID) {
+		result = append(result, i.info)
+	}
+	return result
+}
+
+// Users returns all internal users sorted by ID.
+func (f *Fake) Users() []tidydns.UserInfo {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	result := make([]tidydns.UserInfo, 0, len(f.users))
+	for _, u := range f.users {
+		result = append(result, u.info)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Id < result[j].Id })
+	return result
+}
+
+// Password returns the password of an internal user.
+func (f *Fake) Password(userID tidydns.UserID) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	u, ok := f.users[userID]
+	if !ok {
+		return "", false
+	}
+	return u.password, true
+}
+
+func (f *Fake) GetSubnetIDs(ctx context.Context, subnetCIDR string) (*tidydns.SubnetIDs, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "GetSubnetIDs"); err != nil {
+		return nil, err
+	}
+
+	prefix, err := netip.ParsePrefix(subnetCIDR)
+	if err != nil {
+		return nil, fmt.Errorf("%w: subnet %s", tidydns.ErrNotFound, subnetCIDR)
+	}
+	for _, s := range f.subnets {
+		if s.Prefix == prefix {
+			return &tidydns.SubnetIDs{SubnetID: s.ID, ZoneID: s.ZoneID, VlanNo: s.VlanNo}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: subnet %s", tidydns.ErrNotFound, subnetCIDR)
+}
+
+func (f *Fake) ListSubnets(ctx context.Context, filter tidydns.SubnetFilter) ([]*tidydns.Subnet, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "ListSubnets"); err != nil {
+		return nil, err
+	}
+
+	result := make([]*tidydns.Subnet, 0)
+	for _, s := range f.subnets {
+		subnet := f.subnet(s)
+		if filter.Matches(subnet) {
+			result = append(result, subnet)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, nil
+}
+
+func (f *Fake) GetSubnet(ctx context.Context, subnetID int) (*tidydns.Subnet, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "GetSubnet"); err != nil {
+		return nil, err
+	}
+
+	s, ok := f.subnets[subnetID]
+	if !ok {
+		return nil, notFound("GET", fmt.Sprintf("/=/dhcp_subnet/%d", subnetID))
+	}
+	return f.subnet(s), nil
+}
+
+func (f *Fake) GetSubnetByPrefix(ctx context.Context, prefix netip.Prefix) (*tidydns.Subnet, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "GetSubnetByPrefix"); err != nil {
+		return nil, err
+	}
+	if !prefix.IsValid() {
+		return nil, fmt.Errorf("%w: invalid prefix", tidydns.ErrInvalidArgument)
+	}
+
+	for _, s := range f.subnets {
+		if s.Prefix == prefix.Masked() {
+			return f.subnet(s), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: subnet %s", tidydns.ErrNotFound, prefix.Masked())
+}
+
+func (f *Fake) FindSubnetForIP(ctx context.Context, addr netip.Addr) (*tidydns.Subnet, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "FindSubnetForIP"); err != nil {
+		return nil, err
+	}
+	if !addr.IsValid() {
+		return nil, fmt.Errorf("%w: invalid address", tidydns.ErrInvalidArgument)
+	}
+
+	addr = addr.Unmap()
+	var best *tidydns.Subnet
+	for _, s := range f.subnets {
+		if s.Prefix.Contains(addr) && (best == nil || s.Prefix.Bits() > best.Prefix.Bits()) {
+			best = s
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("%w: no subnet for %s", tidydns.ErrNotFound, addr)
+	}
+	return f.subnet(best), nil
+}
+
+func (f *Fake) CreateSubnet(ctx context.Context, prefix netip.Prefix, settings tidydns.SubnetSettings) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "CreateSubnet"); err != nil {
+		return 0, err
+	}
+	if !prefix.IsValid() || prefix != prefix.Masked() {
+		return 0, fmt.Errorf("%w: invalid subnet %s", tidydns.ErrInvalidArgument, prefix)
+	}
+	if err := settings.Validate(); err != nil {
+		return 0, err
+	}
+
+	subnets := make([]*tidydns.Subnet, 0, len(f.subnets))
+	for _, s := range f.subnets {
+		subnets = append(subnets, f.subnet(s))
+	}
+	sort.Slice(subnets, func(i, j int) bool { return subnets[i].ID < subnets[j].ID })
+	if err := tidydns.CheckSubnetOverlap(prefix, subnets); err != nil {
+		return 0, err
+	}
+	if settings.ZoneID != nil {
+		if _, ok := f.zones[*settings.ZoneID]; !ok {
+			return 0, badRequest("POST", "/=/dhcp_subnet/new", fmt.Sprintf("unknown zone %d", *settings.ZoneID))
+		}
+	}
+
+	family := 6
+	if prefix.Addr().Is4() {
+		family = 4
+	}
+	id := f.newID()
+	now := time.Now().UTC().Truncate(time.Second)
+	subnet := &tidydns.Subnet{
+		ID:           id,
+		Prefix:       prefix,
+		Family:       family,
+		CreatedDate:  now,
+		ModifiedDate: now,
+		ModifiedBy:   Username,
+	}
+	applySubnetSettings(subnet, settings)
+	f.subnets[id] = subnet
+	return id, nil
+}
+
+func (f *Fake) UpdateSubnet(ctx context.Context, subnetID int, settings tidydns.SubnetSettings) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "UpdateSubnet"); err != nil {
+		return err
+	}
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("/=/dhcp_subnet/%d", subnetID)
+	s, ok := f.subnets[subnetID]
+	if !ok {
+		return notFound("POST", path)
+	}
+	if settings.ZoneID != nil {
+		if _, ok := f.zones[*settings.ZoneID]; !ok {
+			return badRequest("POST", path, fmt.Sprintf("unknown zone %d", *settings.ZoneID))
+		}
+	}
+	applySubnetSettings(s, settings)
+	s.ModifiedDate = time.Now().UTC().Truncate(time.Second)
+	s.ModifiedBy = Username
+	return nil
+}
+
+func (f *Fake) DeleteSubnet(ctx context.Context, subnetID int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "DeleteSubnet"); err != nil {
+		return err
+	}
+
+	if _, ok := f.subnets[subnetID]; !ok {
+		return notFound("DELETE", fmt.Sprintf("/=/dhcp_subnet/%d", subnetID))
+	}
+	delete(f.subnets, subnetID)
+	for id, i := range f.interfaces {
+		if i.subnetID == subnetID {
+			delete(f.interfaces, id)
+		}
+	}
+	return nil
+}
+
+func (f *Fake) SubnetUsage(ctx context.Context, subnetID int) (*tidydns.SubnetUsage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "SubnetUsage"); err != nil {
+		return nil, err
+	}
+
+	s, ok := f.subnets[subnetID]
+	if !ok {
+		return nil, notFound("GET", fmt.Sprintf("/=/dhcp_subnet/%d", subnetID))
+	}
+	return f.subnetUsage(s), nil
+}
+
+func (f *Fake) ListSubnetUsage(ctx context.Context, filter tidydns.SubnetFilter) ([]*tidydns.SubnetUsage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "ListSubnetUsage"); err != nil {
+		return nil, err
+	}
+
+	subnets := make([]*tidydns.Subnet, 0)
+	for _, s := range f.subnets {
+		if filter.Matches(f.subnet(s)) {
+			subnets = append(subnets, s)
+		}
+	}
+	sort.Slice(subnets, func(i, j int) bool { return subnets[i].ID < subnets[j].ID })
+
+	result := make([]*tidydns.SubnetUsage, 0, len(subnets))
+	for _, s := range subnets {
+		result = append(result, f.subnetUsage(s))
+	}
+	return result, nil
+}
+
+func (f *Fake) GetFreeIP(ctx context.Context, subnetID int) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "GetFreeIP"); err != nil {
+		return "", err
+	}
+
+	s, ok := f.subnets[subnetID]
+	if !ok {
+		return "", notFound("GET", fmt.Sprintf("/=/dhcp_subnet_free_ip/%d", subnetID))
+	}
+
+	if addr, ok := f.freeAddr(s, nil); ok {
+		return addr.String(), nil
+	}
+	return "", noFreeIP(subnetID)
+}
+
+// noFreeIP returns the error TidyDNS reports when a subnet is full.
+func noFreeIP(subnetID int) error {
+	return &tidydns.APIError{
+		StatusCode: http.StatusInternalServerError,
+		Status:     "500 Internal Server Error",
+		Method:     "GET",
+		Path:       fmt.Sprintf("/=/dhcp_subnet_free_ip/%d", subnetID),
+		Message:    "no free ip address in subnet",
+	}
+}
+
+func (f *Fake) ListDHCPInterfaces(ctx context.Context, subnetID int) ([]*tidydns.InterfaceInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "ListDHCPInterfaces"); err != nil {
+		return nil, err
+	}
+
+	result := make([]*tidydns.InterfaceInfo, 0)
+	for _, i := range f.subnetInterfaces(subnetID) {
+		info := i.info
+		result = append(result, &info)
+	}
+	return result, nil
+}
+
+func (f *Fake) CreateDHCPInterface(ctx context.Context, createInfo tidydns.CreateInfo) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "CreateDHCPInterface"); err != nil {
+		return 0, err
+	}
+
+	const path = "/=/dhcp_interface//new"
+	s, ok := f.subnets[createInfo.SubnetID]
+	if !ok {
+		return 0, notFound("POST", path)
+	}
+	addr, err := netip.ParseAddr(createInfo.InterfaceIP)
+	if err != nil || !s.Prefix.Contains(addr) {
+		return 0, badRequest("POST", path, fmt.Sprintf("invalid destination: %s", createInfo.InterfaceIP))
+	}
+	for _, i := range f.interfaces {
+		if i.info.InterfaceIP == addr.String() {
+			return 0, alreadyExists("POST", path, "destination", addr.String())
+		}
+	}
+
+	createInfo.InterfaceIP = addr.String()
+	return f.addInterface(createInfo).ID, nil
+}
+
+func (f *Fake) AllocateInterface(ctx context.Context, subnetID int, name string, opts tidydns.AllocateOptions) (*tidydns.InterfaceInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "AllocateInterface"); err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		return nil, fmt.Errorf("%w: interface name is empty", tidydns.ErrInvalidArgument)
+	}
+	s, ok := f.subnets[subnetID]
+	if !ok {
+		return nil, notFound("GET", fmt.Sprintf("/=/dhcp_subnet/%d", subnetID))
+	}
+	skip := map[netip.Addr]bool{}
+	for _, addr := range opts.Exclude {
+		skip[addr.Unmap()] = true
+	}
+	// Like the client, report a full subnet with the error of GetFreeIP
+	// and a subnet with only excluded addresses left with an error of its
+	// own.
+	if _, ok := f.freeAddr(s, nil); !ok {
+		return nil, noFreeIP(subnetID)
+	}
+	addr, ok := f.freeAddr(s, skip)
+	if !ok {
+		return nil, fmt.Errorf("no free address in subnet %d", subnetID)
+	}
+
+	zoneID := opts.ZoneID
+	if zoneID == 0 {
+		zoneID = s.ZoneID
+	}
+	info := f.addInterface(tidydns.CreateInfo{
+		SubnetID:      subnetID,
+		ZoneID:        zoneID,
+		InterfaceIP:   addr.String(),
+		InterfaceName: name,
+		LocationID:    opts.LocationID,
+	})
+	return &info, nil
+}
+
+func (f *Fake) ReadDHCPInterface(ctx context.Context, interfaceID int) (*tidydns.InterfaceInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "ReadDHCPInterface"); err != nil {
+		return nil, err
+	}
+
+	i, ok := f.interfaces[interfaceID]
+	if !ok {
+		return nil, notFound("GET", "/=/dhcp_interface/")
+	}
+	info := i.info
+	return &info, nil
+}
+
+func (f *Fake) UpdateDHCPInterfaceName(ctx context.Context, interfaceID int, interfaceName string) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "UpdateDHCPInterfaceName"); err != nil {
+		return 0, err
+	}
+
+	i, ok := f.interfaces[interfaceID]
+	if !ok {
+		return 0, notFound("POST", fmt.Sprintf("/=/dhcp_interface//%d", interfaceID))
+	}
+	i.info.Interfacename = interfaceName
+	return interfaceID, nil
+}
+
+func (f *Fake) DeleteDHCPInterface(ctx context.Context, interfaceID int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "DeleteDHCPInterface"); err != nil {
+		return err
+	}
+
+	if _, ok := f.interfaces[interfaceID]; !ok {
+		return notFound("DELETE", fmt.Sprintf("/=/dhcp_interface/%d", interfaceID))
+	}
+	delete(f.interfaces, interfaceID)
+	return nil
+}
+
+func (f *Fake) ListZones(ctx context.Context) ([]*tidydns.ZoneInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "ListZones"); err != nil {
+		return nil, err
+	}
+
+	result := make([]*tidydns.ZoneInfo, 0, len(f.zones))
+	for _, z := range f.zones {
+		result = append(result, zoneInfo(z))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, nil
+}
+
+func (f *Fake) GetZone(ctx context.Context, zoneID int) (*tidydns.Zone, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "GetZone"); err != nil {
+		return nil, err
+	}
+
+	z, ok := f.zones[zoneID]
+	if !ok {
+		return nil, notFound("GET", fmt.Sprintf("/=/zone/%d", zoneID))
+	}
+	return cloneZone(z), nil
+}
+
+func (f *Fake) ListZonesDetailed(ctx context.Context) ([]*tidydns.Zone, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "ListZonesDetailed"); err != nil {
+		return nil, err
+	}
+
+	result := make([]*tidydns.Zone, 0, len(f.zones))
+	for _, z := range f.zones {
+		result = append(result, cloneZone(z))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, nil
+}
+
+func (f *Fake) CreateZone(ctx context.Context, name string, settings tidydns.ZoneSettings) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "CreateZone"); err != nil {
+		return 0, err
+	}
+	if err := settings.Validate(); err != nil {
+		return 0, err
+	}
+
+	zone, err := f.createZone(name)
+	if err != nil {
+		return 0, err
+	}
+	applyZoneSettings(zone, settings)
+	f.provisionDNSSEC(zone)
+	return zone.ID, nil
+}
+
+// createZone adds a regular zone created through the API.
+func (f *Fake) createZone(name string) (*tidydns.Zone, error) {
+	name = strings.TrimSuffix(name, ".")
+	for _, z := range f.zones {
+		if z.Name == name {
+			return nil, alreadyExists("POST", "/=/zone/new", "name", name)
+		}
+	}
+
+	id := f.newID()
+	now := time.Now().UTC().Truncate(time.Second)
+	zone := &tidydns.Zone{
+		ID:            id,
+		Name:          name,
+		Type:          tidydns.ZoneTypeRegular,
+		TypeText:      tidydns.ZoneTypeRegular.String(),
+		Serial:        1,
+		InjectNS:      true,
+		ProvisionDate: now,
+		CreatedDate:   now,
+		ModifiedDate:  now,
+		ModifiedBy:    Username,
+		DNSSEC:        tidydns.DNSSEC{ParentState: unsignedParentState},
+	}
+	f.zones[id] = zone
+	return zone, nil
+}
+
+func (f *Fake) UpdateZone(ctx context.Context, zoneID int, settings tidydns.ZoneSettings) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "UpdateZone"); err != nil {
+		return err
+	}
+	return f.updateZone(zoneID, settings)
+}
+
+// updateZone must be called with the lock held.
+func (f *Fake) updateZone(zoneID int, settings tidydns.ZoneSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	z, ok := f.zones[zoneID]
+	if !ok {
+		return notFound("POST", fmt.Sprintf("/=/zone/%d", zoneID))
+	}
+	applyZoneSettings(z, settings)
+	// Changes are provisioned right away, bumping the serial.
+	now := time.Now().UTC().Truncate(time.Second)
+	z.Serial++
+	z.ModifiedDate = now
+	z.ModifiedBy = Username
+	z.ProvisionDate = now
+	z.ProvisionLog = ""
+	f.provisionDNSSEC(z)
+	return nil
+}
+
+func (f *Fake) DeleteZone(ctx context.Context, zoneID int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "DeleteZone"); err != nil {
+		return err
+	}
+
+	if _, ok := f.zones[zoneID]; !ok {
+		return notFound("DELETE", fmt.Sprintf("/=/zone/%d", zoneID))
+	}
+	delete(f.zones, zoneID)
+	for id, r := range f.records {
+		if r.zoneID == zoneID {
+			delete(f.records, id)
+		}
+	}
+	return nil
+}
+
+func (f *Fake) ListZoneAliases(ctx context.Context, zoneID int) ([]*tidydns.Zone, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "ListZoneAliases"); err != nil {
+		return nil, err
+	}
+
+	result := make([]*tidydns.Zone, 0)
+	for _, z := range f.zones {
+		if z.Type == tidydns.ZoneTypeAlias && z.AliasID == zoneID {
+			result = append(result, cloneZone(z))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, nil
+}
+
+func (f *Fake) CreateZoneAlias(ctx context.Context, name string, targetZoneID int) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "CreateZoneAlias"); err != nil {
+		return 0, err
+	}
+
+	target, ok := f.zones[targetZoneID]
+	if !ok {
+		return 0, notFound("POST", "/=/zone/new")
+	}
+	if target.Type == tidydns.ZoneTypeAlias {
+		return 0, badRequest("POST", "/=/zone/new", "alias target is an alias zone")
+	}
+	zone, err := f.createZone(name)
+	if err != nil {
+		return 0, err
+	}
+	zone.Type = tidydns.ZoneTypeAlias
+	zone.TypeText = tidydns.ZoneTypeAlias.String()
+	zone.AliasID = target.ID
+	zone.AliasName = target.Name
+	return zone.ID, nil
+}
+
+func (f *Fake) DeleteZoneAlias(ctx context.Context, aliasID int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "DeleteZoneAlias"); err != nil {
+		return err
+	}
+
+	z, ok := f.zones[aliasID]
+	if !ok {
+		return notFound("GET", fmt.Sprintf("/=/zone/%d", aliasID))
+	}
+	if z.Type != tidydns.ZoneTypeAlias {
+		return fmt.Errorf("%w: zone %s is not an alias", tidydns.ErrInvalidArgument, z.Name)
+	}
+	delete(f.zones, aliasID)
+	return nil
+}
+
+// WaitForProvisioned polls the zone like the real client. Zones of the fake
+// are provisioned right away, unless changed with SetZone.
+func (f *Fake) WaitForProvisioned(ctx context.Context, zoneID int, opts tidydns.WaitOptions) (*tidydns.Zone, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = 10 * time.Millisecond
+	}
+
+	var last *tidydns.Zone
+	for {
+		zone, err := f.provisionState(ctx, zoneID)
+		if err != nil {
+			if last == nil {
+				return nil, err
+			}
+			return last, &tidydns.ProvisionError{Zone: last, Log: last.ProvisionLog, Err: err}
+		}
+		if opts.Provisioned(zone) {
+			return zone, nil
+		}
+		last = zone
+
+		select {
+		case <-ctx.Done():
+			return zone, &tidydns.ProvisionError{Zone: zone, Log: zone.ProvisionLog, Err: ctx.Err()}
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (f *Fake) provisionState(ctx context.Context, zoneID int) (*tidydns.Zone, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "WaitForProvisioned"); err != nil {
+		return nil, err
+	}
+
+	z, ok := f.zones[zoneID]
+	if !ok {
+		return nil, notFound("GET", fmt.Sprintf("/=/zone/%d", zoneID))
+	}
+	return cloneZone(z), nil
+}
+
+func (f *Fake) FindReverseZoneForIP(ctx context.Context, addr netip.Addr) (*tidydns.Zone, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "FindReverseZoneForIP"); err != nil {
+		return nil, err
+	}
+
+	z, err := f.reverseZone(addr)
+	if err != nil {
+		return nil, err
+	}
+	return cloneZone(z), nil
+}
+
+func (f *Fake) EnsurePTR(ctx context.Context, addr netip.Addr, fqdn string) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "EnsurePTR"); err != nil {
+		return 0, err
+	}
+	if strings.Trim(fqdn, ".") == "" {
+		return 0, fmt.Errorf("%w: invalid PTR destination %q", tidydns.ErrInvalidArgument, fqdn)
+	}
+	fqdn = strings.TrimSuffix(fqdn, ".") + "."
+
+	z, err := f.reverseZone(addr)
+	if err != nil {
+		return 0, err
+	}
+	name, err := z.PTRName(addr)
+	if err != nil {
+		return 0, err
+	}
+
+	var ids []string
+	var record *fakeRecord
+	for _, r := range f.zoneRecords(z.ID) {
+		if isPTR(r) && r.Name == name {
+			ids = append(ids, strconv.Itoa(r.ID))
+			record = f.records[r.ID]
+		}
+	}
+	if len(ids) > 1 {
+		return 0, fmt.Errorf("%w: %d PTR records for %s in zone %d: %s", tidydns.ErrConflict, len(ids), addr, z.ID, strings.Join(ids, ", "))
+	}
+	if record == nil {
+		id := f.newID()
+		f.records[id] = &fakeRecord{zoneID: z.ID, info: tidydns.RecordInfo{
+			ID:          id,
+			Type:        tidydns.RecordTypeUnknown,
+			TypeName:    "PTR",
+			Name:        name,
+			Destination: fqdn,
+		}}
+		return id, nil
+	}
+	if !strings.EqualFold(strings.TrimSuffix(record.info.Destination, ".")+".", fqdn) {
+		record.info.Destination = fqdn
+	}
+	return record.info.ID, nil
+}
+
+func (f *Fake) GetZoneServerConfig(ctx context.Context, zoneID int) (*tidydns.ZoneServerConfig, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "GetZoneServerConfig"); err != nil {
+		return nil, err
+	}
+
+	z, ok := f.zones[zoneID]
+	if !ok {
+		return nil, notFound("GET", fmt.Sprintf("/=/zone/%d", zoneID))
+	}
+	return tidydns.NewZoneServerConfig(z), nil
+}
+
+func (f *Fake) UpdateZoneServerConfig(ctx context.Context, zoneID int, config tidydns.ZoneServerConfig) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "UpdateZoneServerConfig"); err != nil {
+		return err
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	return f.updateZone(zoneID, config.Settings())
+}
+
+func (f *Fake) SetDNSSEC(ctx context.Context, zoneID int, enabled bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "SetDNSSEC"); err != nil {
+		return err
+	}
+	return f.updateZone(zoneID, tidydns.ZoneSettings{DNSSECEnabled: &enabled})
+}
+
+func (f *Fake) GenerateDNSSECKeys(ctx context.Context, zoneID int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "GenerateDNSSECKeys"); err != nil {
+		return err
+	}
+	generate := true
+	return f.updateZone(zoneID, tidydns.ZoneSettings{DNSSECGenerateKeys: &generate})
+}
+
+func (f *Fake) GetDNSSECStatus(ctx context.Context, zoneID int) (*tidydns.DNSSEC, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "GetDNSSECStatus"); err != nil {
+		return nil, err
+	}
+
+	z, ok := f.zones[zoneID]
+	if !ok {
+		return nil, notFound("GET", fmt.Sprintf("/=/zone/%d", zoneID))
+	}
+	status := z.DNSSEC
+	return &status, nil
+}
+
+func (f *Fake) FindZoneID(ctx context.Context, name string) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "FindZoneID"); err != nil {
+		return 0, err
+	}
+
+	for _, z := range f.zones {
+		if z.Name == name {
+			if aliasID := zoneInfo(z).AliasID; aliasID != 0 {
+				return aliasID, nil
+			}
+			return z.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: zone %s", tidydns.ErrNotFound, name)
+}
+
+func (f *Fake) CreateRecord(ctx context.Context, zoneID int, info tidydns.RecordInfo) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "CreateRecord"); err != nil {
+		return 0, err
+	}
+
+	if _, ok := f.zones[zoneID]; !ok {
+		return 0, notFound("POST", fmt.Sprintf("/=/record/new/%d", zoneID))
+	}
+	if !storeType(&info) {
+		return 0, badRequest("POST", fmt.Sprintf("/=/record/new/%d", zoneID), "unknown record type")
+	}
+	if info.Data != nil && info.Data.RecordType() != info.Type {
+		return 0, badRequest("POST", fmt.Sprintf("/=/record/new/%d", zoneID), "record data does not match type")
+	}
+	if err := validateData(info.Data); err != nil {
+		return 0, err
+	}
+
+	info.ID = f.newID()
+	record := &fakeRecord{zoneID: zoneID, info: info}
+	record.setData(info.Data)
+	f.records[info.ID] = record
+	return info.ID, nil
+}
+
+func (f *Fake) UpdateRecord(ctx context.Context, zoneID int, recordID int, info tidydns.RecordInfo) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "UpdateRecord"); err != nil {
+		return err
+	}
+
+	r, ok := f.records[recordID]
+	if !ok || r.zoneID != zoneID {
+		return notFound("POST", fmt.Sprintf("/=/record/%d/%d", recordID, zoneID))
+	}
+
+	if info.Data != nil && info.Data.RecordType() != r.info.Type {
+		return badRequest("POST", fmt.Sprintf("/=/record/%d/%d", recordID, zoneID), "record data does not match type")
+	}
+	if err := validateData(info.Data); err != nil {
+		return err
+	}
+
+	// Like TidyDNS, the name and type of a record cannot be changed.
+	r.info.TTL = info.TTL
+	r.info.Description = info.Description
+	r.info.Status = info.Status
+	r.info.Destination = info.Destination
+	r.info.Location = info.Location
+	r.setData(info.Data)
+	return nil
+}
+
+func (f *Fake) ReadRecord(ctx context.Context, zoneID int, recordID int) (*tidydns.RecordInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "ReadRecord"); err != nil {
+		return nil, err
+	}
+
+	r, ok := f.records[recordID]
+	if !ok || r.zoneID != zoneID {
+		return nil, notFound("GET", fmt.Sprintf("/=/record/%d/%d", zoneID, recordID))
+	}
+	info := r.info
+	return &info, nil
+}
+
+func (f *Fake) FindRecord(ctx context.Context, zoneID int, name string, rType tidydns.RecordType) ([]*tidydns.RecordInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "FindRecord"); err != nil {
+		return nil, err
+	}
+
+	result := make([]*tidydns.RecordInfo, 0)
+	for _, r := range f.zoneRecords(zoneID) {
+		if hasType(r, rType) && r.Name == name {
+			info := r
+			result = append(result, &info)
+		}
+	}
+	return result, nil
+}
+
+func (f *Fake) ListRecords(ctx context.Context, zoneID int) ([]*tidydns.RecordInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "ListRecords"); err != nil {
+		return nil, err
+	}
+
+	result := make([]*tidydns.RecordInfo, 0)
+	for _, r := range f.zoneRecords(zoneID) {
+		info := r
+		result = append(result, &info)
+	}
+	return result, nil
+}
+
+func (f *Fake) DeleteRecord(ctx context.Context, zoneID int, recordID int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "DeleteRecord"); err != nil {
+		return err
+	}
+
+	r, ok := f.records[recordID]
+	if !ok || r.zoneID != zoneID {
+		return notFound("DELETE", fmt.Sprintf("/=/record/%d/%d", recordID, zoneID))
+	}
+	delete(f.records, recordID)
+	return nil
+}
+
+func (f *Fake) CreateInternalUser(ctx context.Context, username string, password string, description string, changePasswordOnFirstLogin bool, authGroup tidydns.AuthGroup, userAllow []tidydns.UserAllowID) (tidydns.UserID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "CreateInternalUser"); err != nil {
+		return 0, err
+	}
+
+	const path = "/=/user/new"
+	if err := checkAuthGroup("POST", path, authGroup); err != nil {
+		return 0, err
+	}
+	for _, u := range f.users {
+		if u.info.Username == username {
+			return 0, alreadyExists("POST", path, "username", username)
+		}
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	id := tidydns.UserID(f.newID())
+	f.users[id] = &fakeUser{
+		info: tidydns.UserInfo{
+			Description:       description,
+			ModifiedDate:      now,
+			Username:          username,
+			AuthGroup:         authGroup,
+			Name:              username,
+			PasswdChangedDate: now,
+			Id:                id,
+			Groups:            []tidydns.UserInfoGroup{authGroupInfo(authGroup)},
+		},
+		password:  password,
+		userAllow: slices.Clone(userAllow),
+	}
+	return id, nil
+}
+
+func (f *Fake) GetInternalUser(ctx context.Context, userID tidydns.UserID) (*tidydns.UserInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "GetInternalUser"); err != nil {
+		return nil, err
+	}
+
+	u, ok := f.users[userID]
+	if !ok {
+		return nil, notFound("GET", fmt.Sprintf("/=/user/%d", userID))
+	}
+	info := u.info
+	info.Groups = slices.Clone(u.info.Groups)
+	return &info, nil
+}
+
+func (f *Fake) UpdateInternalUser(ctx context.Context, userID tidydns.UserID, password *string, description *string, authGroup *tidydns.AuthGroup, userAllow []tidydns.UserAllowID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "UpdateInternalUser"); err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("/=/user/%d", userID)
+	u, ok := f.users[userID]
+	if !ok {
+		return notFound("POST", path)
+	}
+	if authGroup != nil {
+		if err := checkAuthGroup("POST", path, *authGroup); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	if password != nil {
+		u.password = *password
+		u.info.PasswdChangedDate = now
+	}
+	if description != nil {
+		u.info.Description = *description
+	}
+	if authGroup != nil {
+		u.info.AuthGroup = *authGroup
+		u.info.Groups = []tidydns.UserInfoGroup{authGroupInfo(*authGroup)}
+	}
+	if userAllow != nil {
+		u.userAllow = slices.Clone(userAllow)
+	}
+	u.info.ModifiedDate = now
+	return nil
+}
+
+func (f *Fake) DeleteInternalUser(ctx context.Context, userID tidydns.UserID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check(ctx, "DeleteInternalUser"); err != nil {
+		return err
+	}
+
+	if _, ok := f.users[userID]; !ok {
+		return notFound("DELETE", fmt.Sprintf("/=/user/%d", userID))
+	}
+	delete(f.users, userID)
+	return nil
+}
+
+// check returns the error to fail a method call with, if any.
+func (f *Fake) check(ctx context.Context, method string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return f.errors[method]
+}
+
+func (f *Fake) newID() int {
+	f.nextID++
+	return f.nextID
+}
+
+func (f *Fake) zoneRecords(zoneID int) []tidydns.RecordInfo {
+	result := make([]tidydns.RecordInfo, 0)
+	for _, r := range f.records {
+		if r.zoneID == zoneID {
+			result = append(result, r.info)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// reverseZone returns the reverse zone with the longest prefix covering
+// addr.
+func (f *Fake) reverseZone(addr netip.Addr) (*tidydns.Zone, error) {
+	if !addr.IsValid() {
+		return nil, fmt.Errorf("%w: invalid address", tidydns.ErrInvalidArgument)
+	}
+	addr = addr.Unmap()
+
+	var best *tidydns.Zone
+	bestBits := -1
+	for _, z := range f.zones {
+		prefix, ok := z.ReversePrefix()
+		if ok && prefix.Contains(addr) && prefix.Bits() > bestBits {
+			best, bestBits = z, prefix.Bits()
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("%w: no reverse zone for %s", tidydns.ErrNotFound, addr)
+	}
+	return best, nil
+}
+
+// provisionDNSSEC signs a zone with DNSSEC enabled, like TidyDNS does when
+// provisioning it.
+func (f *Fake) provisionDNSSEC(z *tidydns.Zone) {
+	if !z.DNSSEC.Enabled {
+		return
+	}
+	z.DNSSEC.GenerateKeys = false
+	z.DNSSEC.LastSigned = time.Now().UTC().Truncate(time.Second)
+}
+
+// subnet returns a copy of a subnet with the name of its zone.
+func (f *Fake) subnet(s *tidydns.Subnet) *tidydns.Subnet {
+	subnet := *s
+	if z, ok := f.zones[s.ZoneID]; ok {
+		subnet.ZoneName = z.Name
+	}
+	return &subnet
+}
+
+func (f *Fake) subnetUsage(s *tidydns.Subnet) *tidydns.SubnetUsage {
+	interfaces := make([]*tidydns.InterfaceInfo, 0)
+	for _, i := range f.subnetInterfaces(s.ID) {
+		info := i.info
+		interfaces = append(interfaces, &info)
+	}
+	return tidydns.NewSubnetUsage(s, interfaces)
+}
+
+// freeAddr returns the lowest address of a subnet that is neither assigned
+// to an interface nor in skip, like GetFreeIP.
+func (f *Fake) freeAddr(s *tidydns.Subnet, skip map[netip.Addr]bool) (netip.Addr, bool) {
+	used := map[netip.Addr]bool{}
+	for _, i := range f.interfaces {
+		if addr, err := netip.ParseAddr(i.info.InterfaceIP); err == nil {
+			used[addr] = true
+		}
+	}
+
+	for addr := s.Prefix.Addr().Next(); s.Prefix.Contains(addr); addr = addr.Next() {
+		if addr.Is4() && !s.Prefix.Contains(addr.Next()) {
+			// The last address is the broadcast address.
+			break
+		}
+		if !used[addr] && !skip[addr] {
+			return addr, true
+		}
+	}
+	return netip.Addr{}, false
+}
+
+// addInterface stores a DHCP interface with a new ID.
+func (f *Fake) addInterface(createInfo tidydns.CreateInfo) tidydns.InterfaceInfo {
+	id := f.newID()
+	f.interfaces[id] = &fakeInterface{
+		subnetID:   createInfo.SubnetID,
+		zoneID:     createInfo.ZoneID,
+		locationID: createInfo.LocationID,
+		info: tidydns.InterfaceInfo{
+			ID:            id,
+			InterfaceIP:   createInfo.InterfaceIP,
+			Interfacename: createInfo.InterfaceName,
+		},
+	}
+	return f.interfaces[id].info
+}
+
+func (f *Fake) subnetInterfaces(subnetID int) []*fakeInterface {
+	result := make([]*fakeInterface, 0)
+	for _, i := range f.interfaces {
+		if i.subnetID == subnetID {
+			result = append(result, i)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].info.ID < result[j].info.ID })
+	return result
+}
+
+func applySubnetSettings(sn *tidydns.Subnet, s tidydns.SubnetSettings) {
+	if s.Name != nil {
+		sn.Name = *s.Name
+	}
+	if s.Description != nil {
+		sn.Description = *s.Description
+	}
+	if s.VlanID != nil {
+		sn.VlanID = *s.VlanID
+	}
+	if s.ZoneID != nil {
+		sn.ZoneID = *s.ZoneID
+	}
+	if s.LocationID != nil {
+		sn.LocationID = *s.LocationID
+	}
+	flags := map[*bool]*bool{
+		&sn.DHCPActive:   s.DHCPActive,
+		&sn.DHCPFailover: s.DHCPFailover,
+		&sn.VMPSActive:   s.VMPSActive,
+	}
+	for dst, b := range flags {
+		if b != nil {
+			*dst = *b
+		}
+	}
+}
+
+func applyZoneSettings(z *tidydns.Zone, s tidydns.ZoneSettings) {
+	if s.Description != nil {
+		z.Description = *s.Description
+	}
+	timers := map[*time.Duration]*time.Duration{
+		&z.SOA.TTL:        s.SOATTL,
+		&z.SOA.Refresh:    s.SOARefresh,
+		&z.SOA.Retry:      s.SOARetry,
+		&z.SOA.Expire:     s.SOAExpire,
+		&z.SOA.MinimumTTL: s.SOAMinimumTTL,
+	}
+	for dst, d := range timers {
+		if d != nil {
+			*dst = *d
+		}
+	}
+	if s.SOAContact != nil {
+		z.SOA.Contact = *s.SOAContact
+	}
+	lists := map[*[]string][]string{
+		&z.Masters:       s.Masters,
+		&z.Forwarders:    s.Forwarders,
+		&z.AllowTransfer: s.AllowTransfer,
+	}
+	for dst, list := range lists {
+		if list == nil {
+			continue
+		}
+		*dst = nil
+		if len(list) > 0 {
+			*dst = slices.Clone(list)
+		}
+	}
+	flags := map[*bool]*bool{
+		&z.DNSSEC.Enabled:      s.DNSSECEnabled,
+		&z.DNSSEC.GenerateKeys: s.DNSSECGenerateKeys,
+		&z.DNSSEC.Monitor:      s.DNSSECMonitor,
+		&z.InjectNS:            s.InjectNS,
+		&z.Private:             s.Private,
+		&z.AutofillEnabled:     s.AutofillEnabled,
+	}
+	for dst, b := range flags {
+		if b != nil {
+			*dst = *b
+		}
+	}
+	if s.AutofillTemplate != nil {
+		z.AutofillTemplate = *s.AutofillTemplate
+	}
+}
+
+func zoneInfo(z *tidydns.Zone) *tidydns.ZoneInfo {
+	info := &tidydns.ZoneInfo{ID: z.ID, Name: z.Name}
+	if z.Type == tidydns.ZoneTypeAlias {
+		info.AliasID = z.AliasID
+	}
+	return info
+}
+
+func cloneZone(z *tidydns.Zone) *tidydns.Zone {
+	zone := *z
+	zone.Masters = slices.Clone(z.Masters)
+	zone.Forwarders = slices.Clone(z.Forwarders)
+	zone.AllowTransfer = slices.Clone(z.AllowTransfer)
+	return &zone
+}
+
+// validateData validates record data the way the client does before
+// sending it to TidyDNS.
+func validateData(data tidydns.RecordData) error {
+	if data == nil {
+		return nil
+	}
+	return data.Validate()
+}
+
+func checkAuthGroup(method, path string, authGroup tidydns.AuthGroup) error {
+	switch authGroup {
+	case tidydns.AuthGroupUser, tidydns.AuthGroupSuperAdmin:
+		return nil
+	}
+	return badRequest(method, path, fmt.Sprintf("unknown auth group: %d", authGroup))
+}
+
+func authGroupInfo(authGroup tidydns.AuthGroup) tidydns.UserInfoGroup {
+	name := authGroup.String()
+	return tidydns.UserInfoGroup{GroupName: strings.ToLower(name), Name: name, Id: int(authGroup)}
+}
+
+func notFound(method, path string) error {
+	return &tidydns.APIError{
+		StatusCode: http.StatusNotFound,
+		Status:     "404 Not Found",
+		Method:     method,
+		Path:       path,
+	}
+}
+
+func badRequest(method, path, msg string) error {
+	return &tidydns.APIError{
+		StatusCode: http.StatusBadRequest,
+		Status:     "400 Bad Request",
+		Method:     method,
+		Path:       path,
+		Message:    msg,
+	}
+}
+
+// alreadyExists returns the error TidyDNS reports when a unique constraint
+// is violated.
+func alreadyExists(method, path, key, value string) error {
+	msg := fmt.Sprintf("Key (%s)=(%s) already exists.", key, value)
+	return &tidydns.APIError{
+		StatusCode: http.StatusInternalServerError,
+		Status:     "500 Internal Server Error",
+		Method:     method,
+		Path:       path,
+		Body:       msg,
+		Message:    msg,
+	}
+}
@@ -0,0 +1,434 @@
+package tidydnstest
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/neticdk/tidydns-go/pkg/tidydns"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeDHCP(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake()
+	zoneID := f.AddZone("k8s.netic.dk")
+	subnetID := f.AddSubnet("10.68.0.128/30", zoneID, 534)
+
+	ids, err := f.GetSubnetIDs(ctx, "10.68.0.128/30")
+	assert.NoError(t, err)
+	assert.Equal(t, tidydns.SubnetIDs{SubnetID: subnetID, ZoneID: zoneID, VlanNo: 534}, *ids)
+
+	_, err = f.GetSubnetIDs(ctx, "10.68.1.0/24")
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+
+	ip, err := f.GetFreeIP(ctx, subnetID)
+	assert.NoError(t, err)
+	assert.Equal(t, "10.68.0.129", ip)
+
+	createInfo := tidydns.CreateInfo{SubnetID: subnetID, ZoneID: zoneID, InterfaceIP: ip, InterfaceName: "node1"}
+	id, err := f.CreateDHCPInterface(ctx, createInfo)
+	assert.NoError(t, err)
+
+	_, err = f.CreateDHCPInterface(ctx, createInfo)
+	assert.ErrorIs(t, err, tidydns.ErrAlreadyExists)
+	assert.ErrorIs(t, err, tidydns.ErrConflict)
+
+	ip, err = f.GetFreeIP(ctx, subnetID)
+	assert.NoError(t, err)
+	assert.Equal(t, "10.68.0.130", ip)
+	_, err = f.CreateDHCPInterface(ctx, tidydns.CreateInfo{SubnetID: subnetID, ZoneID: zoneID, InterfaceIP: ip, InterfaceName: "node2"})
+	assert.NoError(t, err)
+
+	_, err = f.GetFreeIP(ctx, subnetID)
+	assert.Error(t, err)
+
+	_, err = f.UpdateDHCPInterfaceName(ctx, id, "node1-renamed")
+	assert.NoError(t, err)
+	info, err := f.ReadDHCPInterface(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, "node1-renamed", info.Interfacename)
+	assert.Equal(t, "10.68.0.129", info.InterfaceIP)
+
+	interfaces, err := f.ListDHCPInterfaces(ctx, subnetID)
+	assert.NoError(t, err)
+	assert.Len(t, interfaces, 2)
+
+	assert.NoError(t, f.DeleteDHCPInterface(ctx, id))
+	assert.ErrorIs(t, f.DeleteDHCPInterface(ctx, id), tidydns.ErrNotFound)
+	assert.Len(t, f.Interfaces(subnetID), 1)
+}
+
+func TestFakeSubnets(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake()
+	zoneID := f.AddZone("k8s.netic.dk")
+	v4 := f.AddSubnet("10.68.0.128/26", zoneID, 534)
+	v6 := f.AddSubnet("2a01:4d0:1::/64", zoneID, 534)
+
+	subnet, err := f.GetSubnet(ctx, v4)
+	assert.NoError(t, err)
+	assert.Equal(t, "k8s.netic.dk", subnet.ZoneName)
+	assert.Equal(t, 4, subnet.Family)
+	subnet.LocationID = 2
+	subnet.VlanName = "utility"
+	f.SetSubnet(*subnet)
+
+	location := tidydns.LocationID(2)
+	subnets, err := f.ListSubnets(ctx, tidydns.SubnetFilter{LocationID: &location})
+	assert.NoError(t, err)
+	assert.Equal(t, []*tidydns.Subnet{subnet}, subnets)
+
+	subnets, err = f.ListSubnets(ctx, tidydns.SubnetFilter{VlanNo: 534, Family: 6})
+	assert.NoError(t, err)
+	if assert.Len(t, subnets, 1) {
+		assert.Equal(t, v6, subnets[0].ID)
+	}
+
+	wide := f.AddSubnet("10.68.0.0/16", zoneID, 1)
+	subnet, err = f.FindSubnetForIP(ctx, netip.MustParseAddr("::ffff:10.68.0.130"))
+	assert.NoError(t, err)
+	assert.Equal(t, v4, subnet.ID)
+	subnet, err = f.FindSubnetForIP(ctx, netip.MustParseAddr("10.68.1.1"))
+	assert.NoError(t, err)
+	assert.Equal(t, wide, subnet.ID)
+
+	subnet, err = f.GetSubnetByPrefix(ctx, netip.MustParsePrefix("2a01:4d0:1::1/64"))
+	assert.NoError(t, err)
+	assert.Equal(t, v6, subnet.ID)
+
+	_, err = f.GetSubnet(ctx, 1)
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+}
+
+func TestFakeSubnetLifecycle(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake()
+	zoneID := f.AddZone("k8s.netic.dk")
+
+	unknownZone := 1
+	_, err := f.CreateSubnet(ctx, netip.MustParsePrefix("10.68.2.0/26"), tidydns.SubnetSettings{ZoneID: &unknownZone})
+	assert.Error(t, err)
+
+	subnetID, err := f.CreateSubnet(ctx, netip.MustParsePrefix("10.68.2.0/26"), tidydns.SubnetSettings{ZoneID: &zoneID})
+	assert.NoError(t, err)
+	_, err = f.CreateSubnet(ctx, netip.MustParsePrefix("10.68.2.32/27"), tidydns.SubnetSettings{})
+	assert.ErrorIs(t, err, tidydns.ErrConflict)
+
+	_, err = f.CreateDHCPInterface(ctx, tidydns.CreateInfo{SubnetID: subnetID, ZoneID: zoneID, InterfaceIP: "10.68.2.1", InterfaceName: "node1"})
+	assert.NoError(t, err)
+	assert.NoError(t, f.DeleteSubnet(ctx, subnetID))
+	assert.Empty(t, f.Interfaces(subnetID))
+	assert.ErrorIs(t, f.UpdateSubnet(ctx, subnetID, tidydns.SubnetSettings{}), tidydns.ErrNotFound)
+}
+
+func TestFakeSubnetUsage(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake()
+	zoneID := f.AddZone("k8s.netic.dk")
+	small := f.AddSubnet("10.68.0.128/30", zoneID, 534)
+	f.AddSubnet("10.68.1.0/24", zoneID, 535)
+
+	_, err := f.CreateDHCPInterface(ctx, tidydns.CreateInfo{SubnetID: small, ZoneID: zoneID, InterfaceIP: "10.68.0.129", InterfaceName: "node1"})
+	assert.NoError(t, err)
+	usage, err := f.SubnetUsage(ctx, small)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), usage.Used)
+	assert.Equal(t, uint64(1), usage.Free)
+	assert.Equal(t, 0.5, usage.Utilization())
+
+	usages, err := f.ListSubnetUsage(ctx, tidydns.SubnetFilter{VlanNo: 535})
+	assert.NoError(t, err)
+	if assert.Len(t, usages, 1) {
+		assert.Equal(t, uint64(254), usages[0].Free)
+	}
+}
+
+func TestFakeAllocateInterface(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake()
+	zoneID := f.AddZone("k8s.netic.dk")
+	subnetID := f.AddSubnet("10.68.0.128/29", zoneID, 534)
+
+	info, err := f.AllocateInterface(ctx, subnetID, "node1", tidydns.AllocateOptions{
+		Exclude: []netip.Addr{netip.MustParseAddr("10.68.0.129"), netip.MustParseAddr("10.68.0.130")},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "10.68.0.131", info.InterfaceIP)
+	assert.Equal(t, "node1", info.Interfacename)
+
+	info, err = f.AllocateInterface(ctx, subnetID, "node2", tidydns.AllocateOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "10.68.0.129", info.InterfaceIP)
+
+	_, err = f.AllocateInterface(ctx, subnetID, "node3", tidydns.AllocateOptions{
+		Exclude: []netip.Addr{netip.MustParseAddr("10.68.0.130"), netip.MustParseAddr("10.68.0.132"), netip.MustParseAddr("10.68.0.133"), netip.MustParseAddr("10.68.0.134")},
+	})
+	assert.ErrorContains(t, err, "no free address")
+	assert.Len(t, f.Interfaces(subnetID), 2)
+
+	for _, name := range []string{"node3", "node4", "node5", "node6"} {
+		_, err = f.AllocateInterface(ctx, subnetID, name, tidydns.AllocateOptions{})
+		assert.NoError(t, err)
+	}
+	_, err = f.AllocateInterface(ctx, subnetID, "node7", tidydns.AllocateOptions{})
+	var apiErr *tidydns.APIError
+	assert.ErrorAs(t, err, &apiErr)
+
+	_, err = f.AllocateInterface(ctx, 1, "node3", tidydns.AllocateOptions{})
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+}
+
+func TestFakeRecords(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake()
+	zoneID := f.AddZone("k8s.netic.dk")
+
+	id, err := f.FindZoneID(ctx, "k8s.netic.dk")
+	assert.NoError(t, err)
+	assert.Equal(t, zoneID, id)
+	_, err = f.FindZoneID(ctx, "netic.dk")
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+
+	recordID, err := f.CreateRecord(ctx, zoneID, tidydns.RecordInfo{
+		Type:        tidydns.RecordTypeA,
+		Name:        "www",
+		Destination: "10.68.1.2",
+		TTL:         300,
+	})
+	assert.NoError(t, err)
+	f.AddRecord(zoneID, tidydns.RecordInfo{Type: tidydns.RecordTypeTXT, Name: "www", Destination: "hello"})
+
+	_, err = f.CreateRecord(ctx, 1, tidydns.RecordInfo{Type: tidydns.RecordTypeA, Name: "www"})
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+
+	found, err := f.FindRecord(ctx, zoneID, "www", tidydns.RecordTypeA)
+	assert.NoError(t, err)
+	assert.Len(t, found, 1)
+	assert.Equal(t, recordID, found[0].ID)
+
+	err = f.UpdateRecord(ctx, zoneID, recordID, tidydns.RecordInfo{Destination: "10.68.1.3", TTL: 60})
+	assert.NoError(t, err)
+	record, err := f.ReadRecord(ctx, zoneID, recordID)
+	assert.NoError(t, err)
+	assert.Equal(t, "10.68.1.3", record.Destination)
+	assert.Equal(t, "www", record.Name)
+	assert.Equal(t, tidydns.RecordTypeA, record.Type)
+
+	records, err := f.ListRecords(ctx, zoneID)
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+
+	assert.NoError(t, f.DeleteRecord(ctx, zoneID, recordID))
+	_, err = f.ReadRecord(ctx, zoneID, recordID)
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+	assert.Len(t, f.Records(zoneID), 1)
+}
+
+func TestFakeRecordData(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake()
+	zoneID := f.AddZone("k8s.netic.dk")
+
+	mx := tidydns.MXData{Preference: 10, Exchange: "mail.netic.dk."}
+	id, err := f.CreateRecord(ctx, zoneID, tidydns.RecordInfo{Type: tidydns.RecordTypeMX, Name: ".", Data: mx})
+	assert.NoError(t, err)
+	record, err := f.ReadRecord(ctx, zoneID, id)
+	assert.NoError(t, err)
+	assert.Equal(t, mx, record.Data)
+	assert.Equal(t, "mail.netic.dk.", record.Destination)
+
+	caa := tidydns.CAAData{Tag: "issue", Value: `letsencrypt.org; "quoted"`}
+	caaID, err := f.CreateRecord(ctx, zoneID, tidydns.RecordInfo{Type: tidydns.RecordTypeCAA, Name: ".", Data: caa})
+	assert.NoError(t, err)
+	record, err = f.ReadRecord(ctx, zoneID, caaID)
+	assert.NoError(t, err)
+	assert.Equal(t, `issue "letsencrypt.org; \"quoted\""`, record.Destination)
+
+	_, err = f.CreateRecord(ctx, zoneID, tidydns.RecordInfo{Type: tidydns.RecordTypeA, Name: "www", Data: mx})
+	assert.Error(t, err)
+	err = f.UpdateRecord(ctx, zoneID, id, tidydns.RecordInfo{Data: tidydns.SRVData{Target: "web.netic.dk"}})
+	assert.Error(t, err)
+	err = f.UpdateRecord(ctx, zoneID, id, tidydns.RecordInfo{Data: tidydns.MXData{}})
+	assert.ErrorIs(t, err, tidydns.ErrInvalidArgument)
+}
+
+func TestFakeReverseZones(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake()
+	f.AddZone("netic.dk")
+	wideID := f.AddReverseZone("68.10.in-addr.arpa", "10.68.0.0/16")
+	zoneID := f.AddReverseZone("1.68.10.in-addr.arpa", "10.68.1.0/24")
+	addr := netip.MustParseAddr("10.68.1.2")
+
+	zone, err := f.FindReverseZoneForIP(ctx, addr)
+	assert.NoError(t, err)
+	assert.Equal(t, zoneID, zone.ID)
+	zone, err = f.FindReverseZoneForIP(ctx, netip.MustParseAddr("10.68.2.2"))
+	assert.NoError(t, err)
+	assert.Equal(t, wideID, zone.ID)
+	_, err = f.FindReverseZoneForIP(ctx, netip.MustParseAddr("2001:db8::1"))
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+
+	f.AddRecord(zoneID, tidydns.RecordInfo{Type: FakePTRRecordType, Name: "2", Destination: "old.netic.dk."})
+	f.AddRecord(zoneID, tidydns.RecordInfo{Type: FakePTRRecordType, Name: "2", Destination: "other.netic.dk."})
+	found, err := f.FindRecord(ctx, zoneID, "2", FakePTRRecordType)
+	assert.NoError(t, err)
+	assert.Len(t, found, 2)
+	found, err = f.FindRecord(ctx, zoneID, "2", tidydns.RecordType(101))
+	assert.NoError(t, err)
+	assert.Empty(t, found)
+	_, err = f.EnsurePTR(ctx, addr, "node1.netic.dk")
+	assert.ErrorIs(t, err, tidydns.ErrConflict)
+	records := f.Records(zoneID)
+	assert.Len(t, records, 2)
+
+	assert.NoError(t, f.DeleteRecord(ctx, zoneID, records[1].ID))
+	id, err := f.EnsurePTR(ctx, addr, "node1.netic.dk")
+	assert.NoError(t, err)
+	records = f.Records(zoneID)
+	assert.Len(t, records, 1)
+	assert.Equal(t, id, records[0].ID)
+	assert.Equal(t, "node1.netic.dk.", records[0].Destination)
+
+	_, err = f.EnsurePTR(ctx, addr, "")
+	assert.ErrorIs(t, err, tidydns.ErrInvalidArgument)
+}
+
+func TestFakeZoneAliases(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake()
+	zoneID := f.AddZone("netic.dk")
+
+	aliasID, err := f.CreateZoneAlias(ctx, "netic.eu", zoneID)
+	assert.NoError(t, err)
+	_, err = f.CreateZoneAlias(ctx, "netic.se", aliasID)
+	assert.ErrorContains(t, err, "alias target is an alias zone")
+	_, err = f.CreateZoneAlias(ctx, "netic.se", 1)
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+
+	id, err := f.FindZoneID(ctx, "netic.eu")
+	assert.NoError(t, err)
+	assert.Equal(t, zoneID, id)
+	assert.Equal(t, []tidydns.ZoneInfo{
+		{ID: zoneID, Name: "netic.dk"},
+		{ID: aliasID, Name: "netic.eu", AliasID: zoneID},
+	}, f.Zones())
+
+	aliases, err := f.ListZoneAliases(ctx, zoneID)
+	assert.NoError(t, err)
+	if assert.Len(t, aliases, 1) {
+		assert.Equal(t, "netic.dk", aliases[0].AliasName)
+	}
+
+	assert.ErrorIs(t, f.DeleteZoneAlias(ctx, zoneID), tidydns.ErrInvalidArgument)
+	assert.NoError(t, f.DeleteZoneAlias(ctx, aliasID))
+	assert.Len(t, f.Zones(), 1)
+}
+
+func TestFakeWaitForProvisioned(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake()
+	zoneID := f.AddZone("netic.dk")
+	zone, err := f.GetZone(ctx, zoneID)
+	assert.NoError(t, err)
+	serial := zone.Serial
+
+	zone.Serial++
+	zone.ModifiedDate = zone.ProvisionDate.Add(time.Second)
+	f.SetZone(*zone)
+	provisioned := *zone
+	provisioned.ProvisionDate = provisioned.ModifiedDate
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		f.SetZone(provisioned)
+	}()
+
+	last, err := f.WaitForProvisioned(ctx, zoneID, tidydns.WaitOptions{Serial: serial, Interval: time.Millisecond, Timeout: 10 * time.Millisecond})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorIs(t, err, tidydns.ErrNotProvisioned)
+	if assert.NotNil(t, last) {
+		assert.Equal(t, serial+1, last.Serial)
+	}
+	zone, err = f.WaitForProvisioned(ctx, zoneID, tidydns.WaitOptions{Serial: serial, Interval: time.Millisecond})
+	assert.NoError(t, err)
+	assert.True(t, zone.Provisioned())
+
+	assert.NoError(t, f.UpdateZone(ctx, zoneID, tidydns.ZoneSettings{}))
+	zone, err = f.WaitForProvisioned(ctx, zoneID, tidydns.WaitOptions{Serial: zone.Serial})
+	assert.NoError(t, err)
+	assert.True(t, zone.Provisioned())
+}
+
+func TestFakeDNSSEC(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake()
+	zoneID := f.AddZone("netic.dk")
+
+	assert.NoError(t, f.SetDNSSEC(ctx, zoneID, true))
+	status, err := f.GetDNSSECStatus(ctx, zoneID)
+	assert.NoError(t, err)
+	assert.True(t, status.Enabled)
+	assert.False(t, status.LastSigned.IsZero())
+
+	assert.NoError(t, f.GenerateDNSSECKeys(ctx, zoneID))
+	status, err = f.GetDNSSECStatus(ctx, zoneID)
+	assert.NoError(t, err)
+	assert.False(t, status.GenerateKeys)
+
+	assert.NoError(t, f.SetDNSSEC(ctx, zoneID, false))
+	status, err = f.GetDNSSECStatus(ctx, zoneID)
+	assert.NoError(t, err)
+	assert.False(t, status.Enabled)
+}
+
+func TestFakeUsers(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake()
+
+	id, err := f.CreateInternalUser(ctx, "test_user", "secret", "desc", false, tidydns.AuthGroupUser, nil)
+	assert.NoError(t, err)
+
+	_, err = f.CreateInternalUser(ctx, "test_user", "secret", "desc", false, tidydns.AuthGroupUser, nil)
+	assert.ErrorIs(t, err, tidydns.ErrAlreadyExists)
+
+	_, err = f.CreateInternalUser(ctx, "other_user", "secret", "desc", false, tidydns.AuthGroup(42), nil)
+	assert.Error(t, err)
+
+	superAdmin := tidydns.AuthGroupSuperAdmin
+	err = f.UpdateInternalUser(ctx, id, toPtr("changed"), nil, &superAdmin, nil)
+	assert.NoError(t, err)
+
+	user, err := f.GetInternalUser(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, "test_user", user.Username)
+	assert.Equal(t, "desc", user.Description)
+	assert.Equal(t, tidydns.AuthGroupSuperAdmin, user.AuthGroup)
+	password, _ := f.Password(id)
+	assert.Equal(t, "changed", password)
+
+	assert.NoError(t, f.DeleteInternalUser(ctx, id))
+	_, err = f.GetInternalUser(ctx, id)
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+	assert.Empty(t, f.Users())
+}
+
+func TestFakeSetError(t *testing.T) {
+	f := NewFake()
+	boom := errors.New("boom")
+
+	f.SetError("ListZones", boom)
+	_, err := f.ListZones(context.Background())
+	assert.ErrorIs(t, err, boom)
+
+	f.SetError("ListZones", nil)
+	_, err = f.ListZones(context.Background())
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = f.ListZones(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,1108 @@
+package tidydnstest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neticdk/tidydns-go/pkg/tidydns"
+)
+
+// Credentials accepted by a Server.
+const (
+	Username = "tidydns"
+	Password = "tidydns"
+)
+
+// dateTimeFormat is the format of timestamps in TidyDNS responses.
+const dateTimeFormat = time.DateTime
+
+// Server is an HTTP server emulating the TidyDNS API endpoints used by the
+// tidydns client. State is kept in memory for the lifetime of the server and
+// can be seeded and inspected through State.
+type Server struct {
+	*httptest.Server
+	state *Fake
+}
+
+// NewServer starts an emulator accepting the credentials Username and
+// Password. The caller must call Close when finished.
+func NewServer() *Server {
+	s := &Server{state: NewFake()}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// State returns the fake holding the state of the server.
+func (s *Server) State() *Fake {
+	return s.state
+}
+
+// Client returns a client configured to talk to the server, using
+// FakePTRRecordType for PTR records.
+func (s *Server) Client(opts ...tidydns.Option) tidydns.TidyDNSClient {
+	opts = append([]tidydns.Option{tidydns.WithPTRRecordType(FakePTRRecordType)}, opts...)
+	c, err := tidydns.NewWithOptions(s.URL, Username, Password, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func (s *Server) serveHTTP(rw http.ResponseWriter, req *http.Request) {
+	username, password, ok := req.BasicAuth()
+	if !ok || username != Username || password != Password {
+		rw.Header().Set("WWW-Authenticate", `Basic realm="TidyDNS"`)
+		writeError(rw, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	path, ok := strings.CutPrefix(req.URL.Path, "/=/")
+	if !ok {
+		writeError(rw, http.StatusNotFound, "not found")
+		return
+	}
+	resource, rest, _ := strings.Cut(path, "/")
+	var args []string
+	for _, a := range strings.Split(rest, "/") {
+		if a != "" {
+			args = append(args, a)
+		}
+	}
+
+	var err error
+	switch resource {
+	case "zone":
+		err = s.zone(rw, req, args)
+	case "record":
+		err = s.record(rw, req, args)
+	case "record_merged":
+		err = s.recordMerged(rw, req)
+	case "dhcp_subnet":
+		err = s.dhcpSubnet(rw, req, args)
+	case "dhcp_subnet_free_ip":
+		err = s.dhcpSubnetFreeIP(rw, req, args)
+	case "dhcp_interface":
+		err = s.dhcpInterface(rw, req, args)
+	case "user":
+		err = s.user(rw, req, args)
+	default:
+		err = errNotFound
+	}
+	if err != nil {
+		writeAPIError(rw, err)
+	}
+}
+
+var (
+	errNotFound         = errors.New("not found")
+	errMethodNotAllowed = errors.New("method not allowed")
+)
+
+func (s *Server) zone(rw http.ResponseWriter, req *http.Request, args []string) error {
+	ctx := req.Context()
+
+	switch {
+	case req.Method == http.MethodGet && len(args) == 0:
+		zones, err := s.state.ListZonesDetailed(ctx)
+		if err != nil {
+			return err
+		}
+
+		name := req.URL.Query().Get("name")
+		result := make([]zoneJSON, 0, len(zones))
+		for _, z := range zones {
+			// Like TidyDNS, the name filter matches substrings.
+			if strings.Contains(z.Name, name) {
+				result = append(result, newZoneJSON(z))
+			}
+		}
+		return writeJSON(rw, result)
+
+	case req.Method == http.MethodPost && len(args) == 1 && args[0] == "new":
+		if err := req.ParseForm(); err != nil {
+			return err
+		}
+		settings, err := zoneForm(req.PostForm)
+		if err != nil {
+			return err
+		}
+		var id int
+		if req.PostForm.Get("type") == strconv.Itoa(int(tidydns.ZoneTypeAlias)) {
+			aliasID, err := strconv.Atoi(req.PostForm.Get("alias_id"))
+			if err != nil {
+				return &tidydns.APIError{StatusCode: http.StatusBadRequest, Message: "invalid alias_id"}
+			}
+			id, err = s.state.CreateZoneAlias(ctx, req.PostForm.Get("name"), aliasID)
+		} else {
+			id, err = s.state.CreateZone(ctx, req.PostForm.Get("name"), settings)
+		}
+		if err != nil {
+			return err
+		}
+		return writeJSON(rw, statusJSON{Status: "0", ID: id})
+
+	case req.Method == http.MethodGet && len(args) == 1:
+		zoneID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return errNotFound
+		}
+		z, err := s.state.GetZone(ctx, zoneID)
+		if err != nil {
+			return err
+		}
+		return writeJSON(rw, newZoneJSON(z))
+
+	case req.Method == http.MethodPost && len(args) == 1:
+		zoneID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return errNotFound
+		}
+		if err := req.ParseForm(); err != nil {
+			return err
+		}
+		settings, err := zoneForm(req.PostForm)
+		if err != nil {
+			return err
+		}
+		if err := s.state.UpdateZone(ctx, zoneID, settings); err != nil {
+			return err
+		}
+		return writeJSON(rw, statusJSON{Status: "0", ID: zoneID})
+
+	case req.Method == http.MethodDelete && len(args) == 1:
+		zoneID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return errNotFound
+		}
+		if err := s.state.DeleteZone(ctx, zoneID); err != nil {
+			return err
+		}
+		return writeJSON(rw, statusJSON{Status: "0"})
+	}
+
+	return errMethodNotAllowed
+}
+
+func (s *Server) record(rw http.ResponseWriter, req *http.Request, args []string) error {
+	ctx := req.Context()
+
+	switch {
+	case req.Method == http.MethodGet && len(args) == 0:
+		q := req.URL.Query()
+		zoneID, _ := strconv.Atoi(q.Get("zone"))
+		records, err := s.state.ListRecords(ctx, zoneID)
+		if err != nil {
+			return err
+		}
+		name := q.Get("name")
+		result := make([]recordJSON, 0)
+		for _, r := range records {
+			if strings.Contains(r.Name, name) {
+				result = append(result, s.recordJSON(zoneID, *r, true))
+			}
+		}
+		return writeJSON(rw, result)
+
+	case req.Method == http.MethodPost && len(args) == 2 && args[0] == "new":
+		zoneID, err := strconv.Atoi(args[1])
+		if err != nil {
+			return errNotFound
+		}
+		info, fields, err := recordForm(req)
+		if err != nil {
+			return err
+		}
+		id, err := s.state.CreateRecord(ctx, zoneID, info)
+		if err != nil {
+			return err
+		}
+		s.setRecordFields(id, fields)
+		return writeJSON(rw, statusJSON{Status: "0", ID: id})
+
+	case req.Method == http.MethodGet && len(args) == 2:
+		zoneID, recordID, err := atoi2(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		r, err := s.state.ReadRecord(ctx, zoneID, recordID)
+		if err != nil {
+			return err
+		}
+		return writeJSON(rw, s.recordJSON(zoneID, *r, false))
+
+	case req.Method == http.MethodPost && len(args) == 2:
+		recordID, zoneID, err := atoi2(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		info, fields, err := recordForm(req)
+		if err != nil {
+			return err
+		}
+		if err := s.state.UpdateRecord(ctx, zoneID, recordID, info); err != nil {
+			return err
+		}
+		s.setRecordFields(recordID, fields)
+		return writeJSON(rw, statusJSON{Status: "0", ID: recordID})
+
+	case req.Method == http.MethodDelete && len(args) == 2:
+		recordID, zoneID, err := atoi2(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		if err := s.state.DeleteRecord(ctx, zoneID, recordID); err != nil {
+			return err
+		}
+		return writeJSON(rw, statusJSON{Status: "0"})
+	}
+
+	return errMethodNotAllowed
+}
+
+func (s *Server) recordMerged(rw http.ResponseWriter, req *http.Request) error {
+	if req.Method != http.MethodGet {
+		return errMethodNotAllowed
+	}
+
+	zoneID, _ := strconv.Atoi(req.URL.Query().Get("zone_id"))
+	records, err := s.state.ListRecords(req.Context(), zoneID)
+	if err != nil {
+		return err
+	}
+
+	result := make([]recordJSON, 0, len(records))
+	for _, r := range records {
+		result = append(result, s.recordJSON(zoneID, *r, true))
+	}
+	return writeJSON(rw, result)
+}
+
+func (s *Server) dhcpSubnet(rw http.ResponseWriter, req *http.Request, args []string) error {
+	ctx := req.Context()
+
+	switch {
+	case req.Method == http.MethodPost && len(args) == 1 && args[0] == "new":
+		if err := req.ParseForm(); err != nil {
+			return err
+		}
+		prefix, err := netip.ParsePrefix(req.PostForm.Get("subnet"))
+		if err != nil {
+			return &tidydns.APIError{StatusCode: http.StatusBadRequest, Message: "invalid subnet"}
+		}
+		settings, err := subnetForm(req.PostForm)
+		if err != nil {
+			return err
+		}
+		id, err := s.state.CreateSubnet(ctx, prefix, settings)
+		if err != nil {
+			return err
+		}
+		return writeJSON(rw, statusJSON{Status: "0", ID: id})
+
+	case len(args) == 1:
+		subnetID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return errNotFound
+		}
+		switch req.Method {
+		case http.MethodGet:
+			sn, err := s.state.GetSubnet(ctx, subnetID)
+			if err != nil {
+				return err
+			}
+			return writeJSON(rw, newSubnetJSON(sn))
+		case http.MethodPost:
+			if err := req.ParseForm(); err != nil {
+				return err
+			}
+			settings, err := subnetForm(req.PostForm)
+			if err != nil {
+				return err
+			}
+			if err := s.state.UpdateSubnet(ctx, subnetID, settings); err != nil {
+				return err
+			}
+			return writeJSON(rw, statusJSON{Status: "0", ID: subnetID})
+		case http.MethodDelete:
+			if err := s.state.DeleteSubnet(ctx, subnetID); err != nil {
+				return err
+			}
+			return writeJSON(rw, statusJSON{Status: "0"})
+		}
+		return errMethodNotAllowed
+
+	case req.Method != http.MethodGet || len(args) > 0:
+		return errMethodNotAllowed
+	}
+
+	filter := req.URL.Query().Get("subnet")
+	method := "ListSubnets"
+	if filter != "" {
+		method = "GetSubnetIDs"
+	}
+
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	if err := s.state.check(ctx, method); err != nil {
+		return err
+	}
+
+	result := make([]subnetJSON, 0)
+	for _, sn := range s.state.subnets {
+		if filter != "" && sn.Prefix.String() != filter {
+			continue
+		}
+		result = append(result, newSubnetJSON(s.state.subnet(sn)))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return writeJSON(rw, result)
+}
+
+func (s *Server) dhcpSubnetFreeIP(rw http.ResponseWriter, req *http.Request, args []string) error {
+	if req.Method != http.MethodGet || len(args) != 1 {
+		return errMethodNotAllowed
+	}
+	subnetID, err := strconv.Atoi(args[0])
+	if err != nil {
+		return errNotFound
+	}
+
+	ip, err := s.state.GetFreeIP(req.Context(), subnetID)
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		Status int `json:"status"`
+		Data   struct {
+			IPAddress string `json:"ip_address"`
+		} `json:"data"`
+	}
+	resp.Data.IPAddress = ip
+	return writeJSON(rw, resp)
+}
+
+func (s *Server) dhcpInterface(rw http.ResponseWriter, req *http.Request, args []string) error {
+	ctx := req.Context()
+
+	switch {
+	case req.Method == http.MethodGet && len(args) == 0:
+		q := req.URL.Query()
+		if q.Has("id") {
+			id, err := strconv.Atoi(q.Get("id"))
+			if err != nil {
+				return errNotFound
+			}
+			if _, err := s.state.ReadDHCPInterface(ctx, id); err != nil {
+				return err
+			}
+			return writeJSON(rw, s.interfaceJSON(id))
+		}
+
+		subnetID, _ := strconv.Atoi(q.Get("subnet_id"))
+		interfaces, err := s.state.ListDHCPInterfaces(ctx, subnetID)
+		if err != nil {
+			return err
+		}
+		result := make([]interfaceJSON, 0, len(interfaces))
+		for _, i := range interfaces {
+			result = append(result, s.interfaceJSON(i.ID))
+		}
+		return writeJSON(rw, result)
+
+	case req.Method == http.MethodPost && len(args) == 1 && args[0] == "new":
+		if err := req.ParseForm(); err != nil {
+			return err
+		}
+		form := req.PostForm
+		createInfo := tidydns.CreateInfo{
+			InterfaceIP:   form.Get("destination"),
+			InterfaceName: form.Get("name"),
+		}
+		createInfo.SubnetID, _ = strconv.Atoi(form.Get("subnet_id"))
+		createInfo.ZoneID, _ = strconv.Atoi(form.Get("zone_id"))
+		createInfo.LocationID, _ = strconv.Atoi(form.Get("location_id"))
+
+		id, err := s.state.CreateDHCPInterface(ctx, createInfo)
+		if err != nil {
+			return err
+		}
+		return writeJSON(rw, interfaceStatusJSON{Status: 0, ID: id, SubnetID: createInfo.SubnetID})
+
+	case req.Method == http.MethodPost && len(args) == 1:
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return errNotFound
+		}
+		if err := req.ParseForm(); err != nil {
+			return err
+		}
+		id, err = s.state.UpdateDHCPInterfaceName(ctx, id, req.PostForm.Get("name"))
+		if err != nil {
+			return err
+		}
+		return writeJSON(rw, interfaceStatusJSON{Status: "0", ID: id, SubnetID: s.interfaceJSON(id).SubnetID})
+
+	case req.Method == http.MethodDelete && len(args) == 1:
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return errNotFound
+		}
+		if err := s.state.DeleteDHCPInterface(ctx, id); err != nil {
+			return err
+		}
+		return writeJSON(rw, statusJSON{Status: "0"})
+	}
+
+	return errMethodNotAllowed
+}
+
+func (s *Server) user(rw http.ResponseWriter, req *http.Request, args []string) error {
+	ctx := req.Context()
+	if len(args) != 1 {
+		return errMethodNotAllowed
+	}
+
+	if req.Method == http.MethodPost && args[0] == "new" {
+		if err := req.ParseForm(); err != nil {
+			return err
+		}
+		form := req.PostForm
+		if form.Get("epassword") != form.Get("epassword_verify") {
+			return &tidydns.APIError{StatusCode: http.StatusBadRequest, Message: "passwords do not match"}
+		}
+		authGroup, err := strconv.Atoi(form.Get("auth_group"))
+		if err != nil {
+			return &tidydns.APIError{StatusCode: http.StatusBadRequest, Message: "invalid auth group"}
+		}
+		id, err := s.state.CreateInternalUser(ctx,
+			form.Get("username"),
+			form.Get("epassword"),
+			form.Get("description"),
+			form.Get("change_password_on_first_login") == "1",
+			tidydns.AuthGroup(authGroup),
+			userAllowForm(form),
+		)
+		if err != nil {
+			return err
+		}
+		return writeJSON(rw, userStatusJSON(id))
+	}
+
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return errNotFound
+	}
+	userID := tidydns.UserID(id)
+
+	switch req.Method {
+	case http.MethodGet:
+		u, err := s.state.GetInternalUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+		return writeJSON(rw, newUserJSON(u))
+
+	case http.MethodPost:
+		if err := req.ParseForm(); err != nil {
+			return err
+		}
+		form := req.PostForm
+		var password, description *string
+		var authGroup *tidydns.AuthGroup
+		if form.Has("epassword") {
+			if form.Get("epassword") != form.Get("epassword_verify") {
+				return &tidydns.APIError{StatusCode: http.StatusBadRequest, Message: "passwords do not match"}
+			}
+			p := form.Get("epassword")
+			password = &p
+		}
+		if form.Has("description") {
+			d := form.Get("description")
+			description = &d
+		}
+		if form.Has("auth_group") {
+			ag, err := strconv.Atoi(form.Get("auth_group"))
+			if err != nil {
+				return &tidydns.APIError{StatusCode: http.StatusBadRequest, Message: "invalid auth group"}
+			}
+			a := tidydns.AuthGroup(ag)
+			authGroup = &a
+		}
+		var userAllow []tidydns.UserAllowID
+		if form.Has("user_allow") {
+			userAllow = userAllowForm(form)
+		}
+		if err := s.state.UpdateInternalUser(ctx, userID, password, description, authGroup, userAllow); err != nil {
+			return err
+		}
+		return writeJSON(rw, userStatusJSON(userID))
+
+	case http.MethodDelete:
+		if err := s.state.DeleteInternalUser(ctx, userID); err != nil {
+			return err
+		}
+		return writeJSON(rw, statusJSON{Status: "0"})
+	}
+
+	return errMethodNotAllowed
+}
+
+type statusJSON struct {
+	Status string `json:"status"`
+	ID     int    `json:"id,omitempty"`
+}
+
+type interfaceStatusJSON struct {
+	Status   interface{} `json:"status"`
+	ID       int         `json:"id"`
+	SubnetID int         `json:"subnet_id"`
+}
+
+type zoneJSON struct {
+	ID                  int     `json:"id"`
+	Name                string  `json:"name"`
+	ZoneName            string  `json:"zone_name"`
+	Description         string  `json:"description"`
+	Type                int     `json:"type"`
+	TypeText            string  `json:"type_text"`
+	Status              int     `json:"status"`
+	CustomerID          int     `json:"customer_id"`
+	ParentID            *int    `json:"parent_id"`
+	Serial              int     `json:"serial"`
+	IsPrivate           int     `json:"is_private"`
+	SOATTL              *int    `json:"soa_ttl"`
+	SOARefresh          *int    `json:"soa_slave_refresh"`
+	SOARetry            *int    `json:"soa_slave_retry"`
+	SOAExpire           *int    `json:"soa_slave_expiration"`
+	SOAMaxCaching       *int    `json:"soa_max_caching"`
+	SOAContact          *string `json:"soa_contact"`
+	DNSSECEnable        int     `json:"dnssec_enable"`
+	DNSSECGenKeys       int     `json:"dnssec_genkeys"`
+	DNSSECMonitorEnable int     `json:"dnssec_monitor_enable"`
+	DNSSECLastSign      *string `json:"dnssec_lastsign"`
+	DNSSECParentState   int     `json:"dnssec_parent_state"`
+	DNSSECParentLog     *string `json:"dnssec_parent_log"`
+	Masters             *string `json:"masters"`
+	Forwarders          *string `json:"forwarders"`
+	AllowTransfer       *string `json:"allow_transfer"`
+	AliasID             *int    `json:"alias_id"`
+	AliasName           *string `json:"alias_name"`
+	AliasType           *int    `json:"alias_type"`
+	Network             *string `json:"network"`
+	RangeStart          *string `json:"range_start"`
+	RangeStop           *string `json:"range_stop"`
+	ReverseNetwork      *string `json:"reverse_network"`
+	ReverseRangeStart   *string `json:"reverse_range_start"`
+	ReverseRangeStop    *string `json:"reverse_range_stop"`
+	ReverseClass        *string `json:"reverse_class"`
+	InjectNSEnable      int     `json:"inject_ns_enable"`
+	ProvisionState      int     `json:"provision_state"`
+	ProvisionDate       *string `json:"provision_date"`
+	ProvisionLog        *string `json:"provision_log"`
+	ServerState         int     `json:"server_state"`
+	AuthoritativeState  int     `json:"authoritative_state"`
+	AuthoritativeLog    *string `json:"authoritative_log"`
+	LastCheckDate       *string `json:"last_check_date"`
+	CreatedDate         *string `json:"created_date"`
+	ModifiedDate        *string `json:"modified_date"`
+	ModifiedBy          *string `json:"modified_by"`
+}
+
+type recordJSON struct {
+	ID          int         `json:"id"`
+	Type        int         `json:"type"`
+	TypeName    string      `json:"type_name"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Destination string      `json:"destination"`
+	TTL         int         `json:"ttl"`
+	Status      interface{} `json:"status"`
+	LocationID  int         `json:"location_id"`
+	ZoneID      int         `json:"zone_id"`
+	ZoneName    string      `json:"zone_name"`
+	Value       *int        `json:"value"`
+	Weight      *int        `json:"weight"`
+	Port        *int        `json:"port"`
+}
+
+type subnetJSON struct {
+	ID             int     `json:"id"`
+	Name           string  `json:"name"`
+	Description    *string `json:"description"`
+	Subnet         string  `json:"subnet"`
+	SubnetNiceName string  `json:"subnet_nice_name"`
+	Family         int     `json:"family"`
+	VlanID         int     `json:"vlan_id"`
+	VlanNo         int     `json:"vlan_no"`
+	VlanName       string  `json:"vlan_name"`
+	Vlan           string  `json:"vlan"`
+	ZoneID         int     `json:"zone_id"`
+	Zone           string  `json:"zone"`
+	LocationID     int     `json:"location_id"`
+	LocName        string  `json:"loc_name"`
+	CustomerID     int     `json:"customer_id"`
+	DHCPActive     int     `json:"dhcp_active"`
+	DHCPFailover   int     `json:"dhcp_failover"`
+	VMPSActive     int     `json:"vmps_active"`
+	SharedNetwork  *string `json:"shared_network"`
+	Status         int     `json:"status"`
+	CreatedDate    *string `json:"created_date"`
+	ModifiedDate   *string `json:"modified_date"`
+	ModifiedBy     *string `json:"modified_by"`
+}
+
+type interfaceJSON struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Destination string `json:"destination"`
+	IPAddress   string `json:"ip_address"`
+	SubnetID    int    `json:"subnet_id"`
+	ZoneID      int    `json:"zone_id"`
+	LocationID  int    `json:"location_id"`
+	RecordID    int    `json:"record_id"`
+}
+
+type userJSON struct {
+	ModifiedBy        string                  `json:"modified_by"`
+	Description       string                  `json:"description"`
+	ModifiedDate      string                  `json:"modified_date"`
+	Username          string                  `json:"username"`
+	AuthGroup         string                  `json:"auth_group"`
+	Name              string                  `json:"name"`
+	Epassword         string                  `json:"epassword"`
+	PasswdChangedDate string                  `json:"passwd_changed_date"`
+	ID                int                     `json:"id"`
+	Groups            []tidydns.UserInfoGroup `json:"groups"`
+}
+
+func (s *Server) recordJSON(zoneID int, r tidydns.RecordInfo, list bool) recordJSON {
+	var status interface{} = int(r.Status)
+	if list {
+		// Record lists report the status as a string.
+		status = strconv.Itoa(int(r.Status))
+	}
+
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	var zoneName string
+	if z, ok := s.state.zones[zoneID]; ok {
+		zoneName = z.Name
+	}
+	fields := make(map[string]*int)
+	if fr, ok := s.state.records[r.ID]; ok {
+		for key, n := range fr.fields {
+			fields[key] = &n
+		}
+	}
+	typeName := r.Type.String()
+	if isPTR(r) {
+		typeName = r.TypeName
+	}
+	return recordJSON{
+		ID:          r.ID,
+		Type:        int(typeID(r)),
+		TypeName:    typeName,
+		Name:        r.Name,
+		Description: r.Description,
+		Destination: r.Destination,
+		TTL:         r.TTL,
+		Status:      status,
+		LocationID:  int(r.Location),
+		ZoneID:      zoneID,
+		ZoneName:    zoneName,
+		Value:       fields["value"],
+		Weight:      fields["weight"],
+		Port:        fields["port"],
+	}
+}
+
+// newSubnetJSON renders a subnet the way TidyDNS does.
+func newSubnetJSON(sn *tidydns.Subnet) subnetJSON {
+	return subnetJSON{
+		ID:             sn.ID,
+		Name:           sn.Name,
+		Description:    nullable(sn.Description),
+		Subnet:         sn.Prefix.String(),
+		SubnetNiceName: strings.TrimSuffix(fmt.Sprintf("%s - %s", sn.Prefix, sn.Name), " - "),
+		Family:         sn.Family,
+		VlanID:         sn.VlanID,
+		VlanNo:         sn.VlanNo,
+		VlanName:       sn.VlanName,
+		Vlan:           strings.TrimSpace(fmt.Sprintf("%d %s", sn.VlanNo, sn.VlanName)),
+		ZoneID:         sn.ZoneID,
+		Zone:           sn.ZoneName,
+		LocationID:     int(sn.LocationID),
+		LocName:        sn.LocationName,
+		CustomerID:     sn.CustomerID,
+		DHCPActive:     boolInt(sn.DHCPActive),
+		DHCPFailover:   boolInt(sn.DHCPFailover),
+		VMPSActive:     boolInt(sn.VMPSActive),
+		SharedNetwork:  nullable(sn.SharedNetwork),
+		Status:         sn.Status,
+		CreatedDate:    nullableTime(sn.CreatedDate),
+		ModifiedDate:   nullableTime(sn.ModifiedDate),
+		ModifiedBy:     nullable(sn.ModifiedBy),
+	}
+}
+
+func (s *Server) interfaceJSON(id int) interfaceJSON {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	i, ok := s.state.interfaces[id]
+	if !ok {
+		return interfaceJSON{ID: id}
+	}
+	return interfaceJSON{
+		ID:          id,
+		Name:        i.info.Interfacename,
+		Destination: i.info.InterfaceIP,
+		IPAddress:   i.info.InterfaceIP,
+		SubnetID:    i.subnetID,
+		ZoneID:      i.zoneID,
+		LocationID:  i.locationID,
+		RecordID:    id,
+	}
+}
+
+// newZoneJSON renders a zone the way TidyDNS does, with null for unset
+// values and lists of hosts as a single string.
+func newZoneJSON(z *tidydns.Zone) zoneJSON {
+	return zoneJSON{
+		ID:                  z.ID,
+		Name:                z.Name,
+		ZoneName:            z.Name,
+		Description:         z.Description,
+		Type:                int(z.Type),
+		TypeText:            z.TypeText,
+		Status:              z.Status,
+		CustomerID:          z.CustomerID,
+		ParentID:            nullable(z.ParentID),
+		Serial:              z.Serial,
+		IsPrivate:           boolInt(z.Private),
+		SOATTL:              nullable(int(z.SOA.TTL.Seconds())),
+		SOARefresh:          nullable(int(z.SOA.Refresh.Seconds())),
+		SOARetry:            nullable(int(z.SOA.Retry.Seconds())),
+		SOAExpire:           nullable(int(z.SOA.Expire.Seconds())),
+		SOAMaxCaching:       nullable(int(z.SOA.MinimumTTL.Seconds())),
+		SOAContact:          nullable(z.SOA.Contact),
+		DNSSECEnable:        boolInt(z.DNSSEC.Enabled),
+		DNSSECGenKeys:       boolInt(z.DNSSEC.GenerateKeys),
+		DNSSECMonitorEnable: boolInt(z.DNSSEC.Monitor),
+		DNSSECLastSign:      nullableTime(z.DNSSEC.LastSigned),
+		DNSSECParentState:   z.DNSSEC.ParentState,
+		DNSSECParentLog:     nullable(z.DNSSEC.ParentLog),
+		Masters:             nullable(strings.Join(z.Masters, ";")),
+		Forwarders:          nullable(strings.Join(z.Forwarders, ";")),
+		AllowTransfer:       nullable(strings.Join(z.AllowTransfer, ";")),
+		AliasID:             nullable(z.AliasID),
+		AliasName:           nullable(z.AliasName),
+		AliasType:           nullable(z.AliasType),
+		Network:             nullable(z.Network),
+		RangeStart:          nullable(z.RangeStart),
+		RangeStop:           nullable(z.RangeStop),
+		ReverseNetwork:      nullable(z.Network),
+		ReverseRangeStart:   nullable(z.RangeStart),
+		ReverseRangeStop:    nullable(z.RangeStop),
+		ReverseClass:        nullable(z.ReverseClass),
+		InjectNSEnable:      boolInt(z.InjectNS),
+		ProvisionState:      z.ProvisionState,
+		ProvisionDate:       nullableTime(z.ProvisionDate),
+		ProvisionLog:        nullable(z.ProvisionLog),
+		ServerState:         z.ServerState,
+		AuthoritativeState:  z.AuthoritativeState,
+		AuthoritativeLog:    nullable(z.AuthoritativeLog),
+		LastCheckDate:       nullableTime(z.LastCheckDate),
+		CreatedDate:         nullableTime(z.CreatedDate),
+		ModifiedDate:        nullableTime(z.ModifiedDate),
+		ModifiedBy:          nullable(z.ModifiedBy),
+	}
+}
+
+func nullable[T comparable](v T) *T {
+	var zero T
+	if v == zero {
+		return nil
+	}
+	return &v
+}
+
+func nullableTime(t time.Time) *string {
+	if t.IsZero() {
+		return nil
+	}
+	s := t.UTC().Format(dateTimeFormat)
+	return &s
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func newUserJSON(u *tidydns.UserInfo) userJSON {
+	return userJSON{
+		ModifiedBy:        Username,
+		Description:       u.Description,
+		ModifiedDate:      u.ModifiedDate.Format(dateTimeFormat),
+		Username:          u.Username,
+		AuthGroup:         u.AuthGroup.String(),
+		Name:              u.Name,
+		Epassword:         "*****",
+		PasswdChangedDate: u.PasswdChangedDate.Format(dateTimeFormat),
+		ID:                int(u.Id),
+		Groups:            u.Groups,
+	}
+}
+
+func userStatusJSON(id tidydns.UserID) interface{} {
+	var resp struct {
+		Data struct {
+			ID int `json:"id"`
+		} `json:"data"`
+		Status string `json:"status"`
+	}
+	resp.Data.ID = int(id)
+	resp.Status = "0"
+	return resp
+}
+
+// recordForm parses a record from a create or update request. The value,
+// weight and port fields holding structured record data are returned
+// separately.
+func recordForm(req *http.Request) (tidydns.RecordInfo, map[string]int, error) {
+	if err := req.ParseForm(); err != nil {
+		return tidydns.RecordInfo{}, nil, err
+	}
+	form := req.PostForm
+
+	info := tidydns.RecordInfo{
+		Name:        form.Get("name"),
+		Description: form.Get("description"),
+		Destination: form.Get("destination"),
+	}
+	ints := map[string]*int{
+		"ttl":         &info.TTL,
+		"type":        (*int)(&info.Type),
+		"status":      (*int)(&info.Status),
+		"location_id": (*int)(&info.Location),
+	}
+	fields := make(map[string]int)
+	for _, key := range recordFields {
+		ints[key] = new(int)
+	}
+	for key, v := range ints {
+		if !form.Has(key) {
+			continue
+		}
+		n, err := strconv.Atoi(form.Get(key))
+		if err != nil {
+			return info, nil, &tidydns.APIError{StatusCode: http.StatusBadRequest, Message: fmt.Sprintf("invalid %s", key)}
+		}
+		*v = n
+		if slices.Contains(recordFields, key) {
+			fields[key] = n
+		}
+	}
+	return info, fields, nil
+}
+
+// zoneForm parses the settings of a zone create or update request.
+func zoneForm(form url.Values) (tidydns.ZoneSettings, error) {
+	var settings tidydns.ZoneSettings
+	if form.Has("description") {
+		settings.Description = toPtr(form.Get("description"))
+	}
+	if form.Has("soa_contact") {
+		settings.SOAContact = toPtr(form.Get("soa_contact"))
+	}
+	timers := map[string]**time.Duration{
+		"soa_ttl":              &settings.SOATTL,
+		"soa_slave_refresh":    &settings.SOARefresh,
+		"soa_slave_retry":      &settings.SOARetry,
+		"soa_slave_expiration": &settings.SOAExpire,
+		"soa_max_caching":      &settings.SOAMinimumTTL,
+	}
+	ints := map[string]**int{
+		"autofill_template": &settings.AutofillTemplate,
+	}
+	for key := range timers {
+		ints[key] = new(*int)
+	}
+	for key, dst := range ints {
+		if !form.Has(key) {
+			continue
+		}
+		n, err := strconv.Atoi(form.Get(key))
+		if err != nil {
+			return settings, &tidydns.APIError{StatusCode: http.StatusBadRequest, Message: fmt.Sprintf("invalid %s", key)}
+		}
+		if d, ok := timers[key]; ok {
+			*d = toPtr(time.Duration(n) * time.Second)
+			continue
+		}
+		*dst = &n
+	}
+	lists := map[string]*[]string{
+		"masters":        &settings.Masters,
+		"forwarders":     &settings.Forwarders,
+		"allow_transfer": &settings.AllowTransfer,
+	}
+	for key, dst := range lists {
+		if form.Has(key) {
+			// An empty value clears the list.
+			*dst = append([]string{}, strings.FieldsFunc(form.Get(key), func(r rune) bool { return r == ';' })...)
+		}
+	}
+	flags := map[string]**bool{
+		"dnssec_enable":         &settings.DNSSECEnabled,
+		"dnssec_genkeys":        &settings.DNSSECGenerateKeys,
+		"dnssec_monitor_enable": &settings.DNSSECMonitor,
+		"inject_ns_enable":      &settings.InjectNS,
+		"is_private":            &settings.Private,
+		"autofill_enable":       &settings.AutofillEnabled,
+	}
+	for key, dst := range flags {
+		if form.Has(key) {
+			*dst = toPtr(form.Get(key) == "1")
+		}
+	}
+	return settings, nil
+}
+
+// subnetForm parses the settings of a subnet create or update request.
+func subnetForm(form url.Values) (tidydns.SubnetSettings, error) {
+	var settings tidydns.SubnetSettings
+	if form.Has("name") {
+		settings.Name = toPtr(form.Get("name"))
+	}
+	if form.Has("description") {
+		settings.Description = toPtr(form.Get("description"))
+	}
+	ints := map[string]**int{
+		"vlan_id":     &settings.VlanID,
+		"zone_id":     &settings.ZoneID,
+		"location_id": new(*int),
+	}
+	for key, dst := range ints {
+		if !form.Has(key) {
+			continue
+		}
+		n, err := strconv.Atoi(form.Get(key))
+		if err != nil {
+			return settings, &tidydns.APIError{StatusCode: http.StatusBadRequest, Message: fmt.Sprintf("invalid %s", key)}
+		}
+		if key == "location_id" {
+			settings.LocationID = toPtr(tidydns.LocationID(n))
+			continue
+		}
+		*dst = &n
+	}
+	flags := map[string]**bool{
+		"dhcp_active":   &settings.DHCPActive,
+		"dhcp_failover": &settings.DHCPFailover,
+		"vmps_active":   &settings.VMPSActive,
+	}
+	for key, dst := range flags {
+		if form.Has(key) {
+			*dst = toPtr(form.Get(key) == "1")
+		}
+	}
+	return settings, nil
+}
+
+func toPtr[T any](v T) *T {
+	return &v
+}
+
+// recordFields are the fields of a record that hold the leading numbers
+// of structured record data.
+var recordFields = []string{"value", "weight", "port"}
+
+func (s *Server) setRecordFields(recordID int, fields map[string]int) {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	r, ok := s.state.records[recordID]
+	if !ok {
+		return
+	}
+	if r.fields == nil {
+		r.fields = make(map[string]int)
+	}
+	for key, n := range fields {
+		r.fields[key] = n
+	}
+}
+
+func userAllowForm(form url.Values) []tidydns.UserAllowID {
+	result := make([]tidydns.UserAllowID, 0)
+	for _, v := range form["user_allow"] {
+		if id, err := strconv.Atoi(v); err == nil {
+			result = append(result, tidydns.UserAllowID(id))
+		}
+	}
+	return result
+}
+
+func atoi2(a, b string) (int, int, error) {
+	x, err := strconv.Atoi(a)
+	if err != nil {
+		return 0, 0, errNotFound
+	}
+	y, err := strconv.Atoi(b)
+	if err != nil {
+		return 0, 0, errNotFound
+	}
+	return x, y, nil
+}
+
+func writeJSON(rw http.ResponseWriter, v interface{}) error {
+	rw.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(rw).Encode(v)
+}
+
+func writeError(rw http.ResponseWriter, status int, msg string) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	_ = json.NewEncoder(rw).Encode(map[string]string{"status": "1", "error": msg})
+}
+
+// writeAPIError writes an error returned by the state as an HTTP response.
+func writeAPIError(rw http.ResponseWriter, err error) {
+	var apiErr *tidydns.APIError
+	switch {
+	case errors.As(err, &apiErr):
+		msg := apiErr.Message
+		if msg == "" {
+			msg = http.StatusText(apiErr.StatusCode)
+		}
+		writeError(rw, apiErr.StatusCode, msg)
+	case errors.Is(err, errNotFound), errors.Is(err, tidydns.ErrNotFound):
+		writeError(rw, http.StatusNotFound, err.Error())
+	case errors.Is(err, errMethodNotAllowed):
+		writeError(rw, http.StatusMethodNotAllowed, err.Error())
+	case errors.Is(err, tidydns.ErrConflict):
+		writeError(rw, http.StatusConflict, err.Error())
+	case errors.Is(err, context.Canceled):
+		writeError(rw, http.StatusServiceUnavailable, err.Error())
+	default:
+		writeError(rw, http.StatusInternalServerError, err.Error())
+	}
+}
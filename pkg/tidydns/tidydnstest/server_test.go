@@ -0,0 +1,338 @@
+package tidydnstest
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neticdk/tidydns-go/pkg/tidydns"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerAuthentication(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	c := tidydns.New(s.URL, Username, "wrong")
+	_, err := c.ListZones(context.Background())
+	assert.ErrorIs(t, err, tidydns.ErrUnauthorized)
+}
+
+func TestServerDHCP(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer()
+	defer s.Close()
+
+	zoneID := s.State().AddZone("k8s.netic.dk")
+	s.State().AddSubnet("10.68.0.128/26", zoneID, 534)
+	c := s.Client()
+
+	ids, err := c.GetSubnetIDs(ctx, "10.68.0.128/26")
+	assert.NoError(t, err)
+	assert.Equal(t, zoneID, ids.ZoneID)
+	assert.Equal(t, 534, ids.VlanNo)
+
+	_, err = c.GetSubnetIDs(ctx, "10.68.1.0/24")
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+
+	ip, err := c.GetFreeIP(ctx, ids.SubnetID)
+	assert.NoError(t, err)
+	assert.Equal(t, "10.68.0.129", ip)
+
+	createInfo := tidydns.CreateInfo{
+		SubnetID:      ids.SubnetID,
+		ZoneID:        ids.ZoneID,
+		InterfaceIP:   ip,
+		InterfaceName: "node1",
+		LocationID:    1,
+	}
+	id, err := c.CreateDHCPInterface(ctx, createInfo)
+	assert.NoError(t, err)
+
+	_, err = c.CreateDHCPInterface(ctx, createInfo)
+	assert.ErrorIs(t, err, tidydns.ErrAlreadyExists)
+
+	next, err := c.GetFreeIP(ctx, ids.SubnetID)
+	assert.NoError(t, err)
+	assert.Equal(t, "10.68.0.130", next)
+
+	updatedID, err := c.UpdateDHCPInterfaceName(ctx, id, "node1-renamed")
+	assert.NoError(t, err)
+	assert.Equal(t, id, updatedID)
+
+	info, err := c.ReadDHCPInterface(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, "node1-renamed", info.Interfacename)
+	assert.Equal(t, ip, info.InterfaceIP)
+
+	interfaces, err := c.ListDHCPInterfaces(ctx, ids.SubnetID)
+	assert.NoError(t, err)
+	assert.Len(t, interfaces, 1)
+
+	assert.NoError(t, c.DeleteDHCPInterface(ctx, id))
+	assert.ErrorIs(t, c.DeleteDHCPInterface(ctx, id), tidydns.ErrNotFound)
+	assert.Empty(t, s.State().Interfaces(ids.SubnetID))
+}
+
+func TestServerAllocateInterfaceConcurrently(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer()
+	defer s.Close()
+
+	zoneID := s.State().AddZone("k8s.netic.dk")
+	subnetID := s.State().AddSubnet("10.68.0.128/28", zoneID, 534)
+	c := s.Client()
+
+	const workers = 4
+	addrs := make([]string, workers)
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			info, err := c.AllocateInterface(ctx, subnetID, fmt.Sprintf("node%d", i), tidydns.AllocateOptions{Backoff: time.Millisecond})
+			errs[i] = err
+			if err == nil {
+				addrs[i] = info.InterfaceIP
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+	slices.Sort(addrs)
+	assert.Equal(t, []string{"10.68.0.129", "10.68.0.130", "10.68.0.131", "10.68.0.132"}, addrs)
+}
+
+func TestServerRecords(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer()
+	defer s.Close()
+
+	s.State().AddZone("netic.dk")
+	zoneID := s.State().AddZone("k8s.netic.dk")
+	c := s.Client()
+
+	zones, err := c.ListZones(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, zones, 2)
+
+	id, err := c.FindZoneID(ctx, "netic.dk")
+	assert.NoError(t, err)
+	assert.NotEqual(t, zoneID, id)
+	id, err = c.FindZoneID(ctx, "k8s.netic.dk")
+	assert.NoError(t, err)
+	assert.Equal(t, zoneID, id)
+
+	recordID, err := c.CreateRecord(ctx, zoneID, tidydns.RecordInfo{
+		Type:        tidydns.RecordTypeA,
+		Name:        "www",
+		Destination: "10.68.1.2",
+		TTL:         300,
+		Description: "web",
+	})
+	assert.NoError(t, err)
+	_, err = c.CreateRecord(ctx, zoneID, tidydns.RecordInfo{
+		Type:        tidydns.RecordTypeA,
+		Name:        "www2",
+		Destination: "10.68.1.3",
+	})
+	assert.NoError(t, err)
+
+	found, err := c.FindRecord(ctx, zoneID, "www", tidydns.RecordTypeA)
+	assert.NoError(t, err)
+	assert.Len(t, found, 1)
+	assert.Equal(t, recordID, found[0].ID)
+
+	err = c.UpdateRecord(ctx, zoneID, recordID, tidydns.RecordInfo{
+		Destination: "10.68.1.4",
+		TTL:         60,
+		Status:      tidydns.RecordStatusInactive,
+	})
+	assert.NoError(t, err)
+
+	record, err := c.ReadRecord(ctx, zoneID, recordID)
+	assert.NoError(t, err)
+	assert.Equal(t, "www", record.Name)
+	assert.Equal(t, "10.68.1.4", record.Destination)
+	assert.Equal(t, 60, record.TTL)
+	assert.Equal(t, tidydns.RecordStatusInactive, record.Status)
+
+	records, err := c.ListRecords(ctx, zoneID)
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+
+	assert.NoError(t, c.DeleteRecord(ctx, zoneID, recordID))
+	_, err = c.ReadRecord(ctx, zoneID, recordID)
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+}
+
+func TestServerZones(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer()
+	defer s.Close()
+
+	zoneID := s.State().AddZone("netic.dk")
+	zone, err := s.State().GetZone(ctx, zoneID)
+	assert.NoError(t, err)
+	zone.Serial = 42
+	zone.AllowTransfer = []string{"10.0.0.1", "10.0.0.2"}
+	zone.SOA = tidydns.SOA{TTL: time.Hour, Contact: "hostmaster.netic.dk"}
+	zone.DNSSEC.Enabled = true
+	zone.DNSSEC.LastSigned = time.Date(2021, 10, 4, 13, 38, 56, 0, time.UTC)
+	zone.ProvisionLog = "ok"
+	s.State().SetZone(*zone)
+	c := s.Client()
+
+	got, err := c.GetZone(ctx, zoneID)
+	assert.NoError(t, err)
+	assert.Equal(t, zone, got)
+
+	zones, err := c.ListZonesDetailed(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []*tidydns.Zone{zone}, zones)
+
+	_, err = c.GetZone(ctx, zoneID+1)
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+}
+
+func TestServerReverseZones(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer()
+	defer s.Close()
+
+	zoneID := s.State().AddReverseZone("0-25.1.68.10.in-addr.arpa", "10.68.1.0/25")
+	c := s.Client()
+	addr := netip.MustParseAddr("10.68.1.2")
+
+	zone, err := c.FindReverseZoneForIP(ctx, addr)
+	assert.NoError(t, err)
+	assert.Equal(t, zoneID, zone.ID)
+	assert.Equal(t, "10.68.1.0/25", zone.Network)
+
+	id, err := c.EnsurePTR(ctx, addr, "node1.netic.dk")
+	assert.NoError(t, err)
+	again, err := c.EnsurePTR(ctx, addr, "node2.netic.dk")
+	assert.NoError(t, err)
+	assert.Equal(t, id, again)
+
+	records := s.State().Records(zoneID)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "2", records[0].Name)
+	assert.Equal(t, tidydns.RecordTypeUnknown, records[0].Type)
+	assert.Equal(t, "PTR", records[0].TypeName)
+	assert.Equal(t, "node2.netic.dk.", records[0].Destination)
+}
+
+func TestServerUsers(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer()
+	defer s.Close()
+	c := s.Client()
+
+	id, err := c.CreateInternalUser(ctx, "test_user", "secret", "desc", true, tidydns.AuthGroupUser, []tidydns.UserAllowID{1, 2})
+	assert.NoError(t, err)
+
+	_, err = c.CreateInternalUser(ctx, "test_user", "secret", "desc", true, tidydns.AuthGroupUser, nil)
+	assert.ErrorIs(t, err, tidydns.ErrAlreadyExists)
+
+	user, err := c.GetInternalUser(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, "test_user", user.Username)
+	assert.Equal(t, tidydns.AuthGroupUser, user.AuthGroup)
+	assert.Equal(t, "desc", user.Description)
+
+	superAdmin := tidydns.AuthGroupSuperAdmin
+	err = c.UpdateInternalUser(ctx, id, toPtr("changed"), nil, &superAdmin, nil)
+	assert.NoError(t, err)
+
+	user, err = c.GetInternalUser(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, tidydns.AuthGroupSuperAdmin, user.AuthGroup)
+	assert.Equal(t, "desc", user.Description)
+	password, _ := s.State().Password(id)
+	assert.Equal(t, "changed", password)
+
+	assert.NoError(t, c.DeleteInternalUser(ctx, id))
+	_, err = c.GetInternalUser(ctx, id)
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+}
+
+func TestServerRecordData(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer()
+	defer s.Close()
+
+	zoneID := s.State().AddZone("k8s.netic.dk")
+	c := s.Client()
+
+	srv := tidydns.SRVData{Priority: 10, Weight: 5, Port: 443, Target: "web.k8s.netic.dk."}
+	recordID, err := c.CreateRecord(ctx, zoneID, tidydns.RecordInfo{
+		Type: tidydns.RecordTypeSRV,
+		Name: "_https._tcp",
+		Data: srv,
+	})
+	assert.NoError(t, err)
+
+	record, err := c.ReadRecord(ctx, zoneID, recordID)
+	assert.NoError(t, err)
+	assert.Equal(t, srv, record.Data)
+	assert.Equal(t, "web.k8s.netic.dk.", record.Destination)
+
+	srv.Port = 8443
+	assert.NoError(t, c.UpdateRecord(ctx, zoneID, recordID, tidydns.RecordInfo{Data: srv}))
+
+	records, err := c.ListRecords(ctx, zoneID)
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, srv, records[0].Data)
+
+	_, err = c.CreateRecord(ctx, zoneID, tidydns.RecordInfo{
+		Type: tidydns.RecordTypeMX,
+		Name: ".",
+		Data: tidydns.MXData{Preference: 10, Exchange: "bad name"},
+	})
+	assert.ErrorIs(t, err, tidydns.ErrInvalidArgument)
+}
+
+func TestServerZoneLifecycle(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer()
+	defer s.Close()
+	c := s.Client()
+
+	zoneID, err := c.CreateZone(ctx, "example.com", tidydns.ZoneSettings{
+		SOAContact: toPtr("hostmaster@example.com"),
+		Masters:    []string{"192.0.2.1", "192.0.2.2"},
+		Private:    toPtr(true),
+	})
+	assert.NoError(t, err)
+	recordID := s.State().AddRecord(zoneID, tidydns.RecordInfo{Type: tidydns.RecordTypeA, Name: "www", Destination: "192.0.2.10"})
+
+	zone, err := c.GetZone(ctx, zoneID)
+	assert.NoError(t, err)
+	assert.Equal(t, "hostmaster@example.com", zone.SOA.Contact)
+	assert.Equal(t, []string{"192.0.2.1", "192.0.2.2"}, zone.Masters)
+	assert.True(t, zone.Private)
+	serial := zone.Serial
+
+	err = c.UpdateZone(ctx, zoneID, tidydns.ZoneSettings{Masters: []string{}, SOARetry: toPtr(time.Minute)})
+	assert.NoError(t, err)
+	zone, err = c.GetZone(ctx, zoneID)
+	assert.NoError(t, err)
+	assert.Nil(t, zone.Masters)
+	assert.Equal(t, time.Minute, zone.SOA.Retry)
+	assert.Equal(t, "hostmaster@example.com", zone.SOA.Contact)
+	assert.Greater(t, zone.Serial, serial)
+
+	assert.NoError(t, c.DeleteZone(ctx, zoneID))
+	_, err = c.ReadRecord(ctx, zoneID, recordID)
+	assert.ErrorIs(t, err, tidydns.ErrNotFound)
+}
@@ -30,30 +30,49 @@ type interfaceCreate struct {
 }
 
 type zoneInfo struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
+	ID      int      `json:"id"`
+	Name    string   `json:"name"`
+	Type    ZoneType `json:"type"`
+	AliasID int      `json:"alias_id"`
+}
+
+// aliasID returns the zone mirrored by an alias zone, or 0.
+func (z zoneInfo) aliasID() int {
+	if z.Type != ZoneTypeAlias {
+		return 0
+	}
+	return z.AliasID
 }
 
 type recordRead struct {
 	ID          int          `json:"id"`
 	Type        RecordType   `json:"type"`
+	TypeName    string       `json:"type_name"`
 	Name        string       `json:"name"`
 	Description string       `json:"description"`
 	Destination string       `json:"destination"`
 	TTL         int          `json:"ttl"`
 	Status      RecordStatus `json:"status"`
 	Location    LocationID   `json:"location_id"`
+	Value       nullableInt  `json:"value"`
+	Weight      nullableInt  `json:"weight"`
+	Port        nullableInt  `json:"port"`
 }
 
 type recordList struct {
-	ID          int         `json:"id"`
-	Type        RecordType  `json:"type"`
-	Name        string      `json:"name"`
-	Description string      `json:"description"`
-	Destination string      `json:"destination"`
-	TTL         int         `json:"ttl"`
-	Status      interface{} `json:"status"`
-	Location    LocationID  `json:"location_id"`
+	ID          int        `json:"id"`
+	Type        RecordType `json:"type"`
+	TypeName    string     `json:"type_name"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Destination string     `json:"destination"`
+	TTL         int        `json:"ttl"`
+	// Status is reported as a string in record lists.
+	Status   RecordStatus `json:"status"`
+	Location LocationID   `json:"location_id"`
+	Value    nullableInt  `json:"value"`
+	Weight   nullableInt  `json:"weight"`
+	Port     nullableInt  `json:"port"`
 }
 
 type userCreate struct {
@@ -75,3 +94,33 @@ type userRead struct {
 	Id                int             `json:"id"`
 	Groups            []UserInfoGroup `json:"groups"`
 }
+
+func (r recordList) recordType() RecordType {
+	return resolveRecordType(r.Type, r.TypeName)
+}
+
+// hasType reports whether a record is of the given type. Types not known by
+// this package are compared by the number TidyDNS reports, while
+// RecordTypeUnknown matches every record of such a type.
+func (r recordList) hasType(t RecordType) bool {
+	if t.known() || t == RecordTypeUnknown {
+		return r.recordType() == t
+	}
+	return r.Type == t
+}
+
+func (r recordList) info() *RecordInfo {
+	t := r.recordType()
+	return &RecordInfo{
+		ID:          r.ID,
+		Type:        t,
+		TypeName:    r.TypeName,
+		Name:        r.Name,
+		Description: r.Description,
+		Destination: r.Destination,
+		TTL:         r.TTL,
+		Status:      r.Status,
+		Location:    r.Location,
+		Data:        decodeRecordData(t, recordFields{r.Destination, r.Value, r.Weight, r.Port}),
+	}
+}
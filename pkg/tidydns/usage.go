@@ -0,0 +1,197 @@
+package tidydns
+
+import (
+	"context"
+	"math"
+	"net/netip"
+	"slices"
+	"sync"
+)
+
+// subnetUsageWorkers is the number of subnets ListSubnetUsage reads at the
+// same time. WithMaxInFlight limits the requests further.
+const subnetUsageWorkers = 8
+
+// SubnetUsage is the address utilization of a DHCP subnet.
+type SubnetUsage struct {
+	SubnetID int
+	Prefix   netip.Prefix
+	// Total is the number of addresses in the subnet. Total and Free
+	// saturate at math.MaxUint64 for IPv6 subnets of /64 and larger.
+	Total uint64
+	// Used is the number of addresses assigned to DHCP interfaces.
+	Used uint64
+	// Reserved is the number of addresses that cannot be assigned: the
+	// network and broadcast addresses of IPv4 subnets and the subnet-router
+	// anycast address of IPv6 subnets.
+	Reserved uint64
+	Free     uint64
+	// FreeRanges are the unassigned addresses in ascending order.
+	FreeRanges []AddressRange
+}
+
+// AddressRange is a range of consecutive addresses, both ends included.
+type AddressRange struct {
+	First netip.Addr
+	Last  netip.Addr
+}
+
+// Utilization returns the share of assignable addresses in use, between 0
+// and 1.
+func (u *SubnetUsage) Utilization() float64 {
+	assignable := float64(u.Used) + float64(u.Free)
+	if assignable == 0 {
+		return 1
+	}
+	return float64(u.Used) / assignable
+}
+
+// NewSubnetUsage returns the utilization of a subnet given its DHCP
+// interfaces. Interfaces outside the assignable addresses of the subnet are
+// ignored.
+func NewSubnetUsage(subnet *Subnet, interfaces []*InterfaceInfo) *SubnetUsage {
+	prefix := subnet.Prefix.Masked()
+	usage := &SubnetUsage{
+		SubnetID: subnet.ID,
+		Prefix:   prefix,
+		Total:    prefixSize(prefix),
+	}
+
+	first, last := prefix.Addr(), lastAddr(prefix)
+	if hostBits := prefix.Addr().BitLen() - prefix.Bits(); hostBits >= 2 {
+		// Like GetFreeIP, never hand out the first address, nor the
+		// broadcast address of IPv4 subnets.
+		first = first.Next()
+		usage.Reserved = 1
+		if prefix.Addr().Is4() {
+			last = last.Prev()
+			usage.Reserved = 2
+		}
+	}
+
+	used := make([]netip.Addr, 0, len(interfaces))
+	for _, i := range interfaces {
+		addr, err := netip.ParseAddr(i.InterfaceIP)
+		if err != nil {
+			continue
+		}
+		addr = addr.Unmap()
+		if addr.Less(first) || last.Less(addr) {
+			continue
+		}
+		used = append(used, addr)
+	}
+	slices.SortFunc(used, netip.Addr.Compare)
+	used = slices.Compact(used)
+
+	usage.Used = uint64(len(used))
+	usage.Free = usage.Total - usage.Reserved - usage.Used
+	if usage.Total == math.MaxUint64 {
+		usage.Free = math.MaxUint64
+	}
+
+	// Walk the gaps between used addresses rather than every address, as
+	// IPv6 subnets are far too large to enumerate.
+	start := first
+	for _, addr := range used {
+		if start.Less(addr) {
+			usage.FreeRanges = append(usage.FreeRanges, AddressRange{First: start, Last: addr.Prev()})
+		}
+		start = addr.Next()
+	}
+	if start.IsValid() && !last.Less(start) {
+		usage.FreeRanges = append(usage.FreeRanges, AddressRange{First: start, Last: last})
+	}
+	return usage
+}
+
+// prefixSize returns the number of addresses in a prefix, saturating at
+// math.MaxUint64.
+func prefixSize(prefix netip.Prefix) uint64 {
+	hostBits := prefix.Addr().BitLen() - prefix.Bits()
+	if hostBits >= 64 {
+		return math.MaxUint64
+	}
+	return 1 << hostBits
+}
+
+// lastAddr returns the highest address in a prefix.
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	b := prefix.Masked().Addr().AsSlice()
+	for i := prefix.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 1 << (7 - i%8)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
+
+func (c *tidyDNSClient) SubnetUsage(ctx context.Context, subnetID int) (_ *SubnetUsage, err error) {
+	ctx, end := c.startOperation(ctx, "SubnetUsage", attrSubnetID.Int(subnetID))
+	defer func() { end(err) }()
+
+	subnet, err := c.GetSubnet(ctx, subnetID)
+	if err != nil {
+		return nil, err
+	}
+	interfaces, err := c.ListDHCPInterfaces(ctx, subnetID)
+	if err != nil {
+		return nil, err
+	}
+	return NewSubnetUsage(subnet, interfaces), nil
+}
+
+func (c *tidyDNSClient) ListSubnetUsage(ctx context.Context, filter SubnetFilter) (_ []*SubnetUsage, err error) {
+	ctx, end := c.startOperation(ctx, "ListSubnetUsage")
+	defer func() { end(err) }()
+
+	subnets, err := c.ListSubnets(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	result := make([]*SubnetUsage, len(subnets))
+	jobs := make(chan int)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	for range min(subnetUsageWorkers, len(subnets)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				interfaces, err := c.ListDHCPInterfaces(ctx, subnets[i].ID)
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+						cancel()
+					}
+					mu.Unlock()
+					continue
+				}
+				result[i] = NewSubnetUsage(subnets[i], interfaces)
+			}
+		}()
+	}
+	for i := range subnets {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,145 @@
+package tidydns
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func addrRange(first, last string) AddressRange {
+	return AddressRange{First: netip.MustParseAddr(first), Last: netip.MustParseAddr(last)}
+}
+
+func TestNewSubnetUsage(t *testing.T) {
+	subnet := &Subnet{ID: 1185, Prefix: netip.MustParsePrefix("10.68.0.128/26")}
+	interfaces := []*InterfaceInfo{
+		{InterfaceIP: "10.68.0.129"},
+		{InterfaceIP: "10.68.0.134"},
+		{InterfaceIP: "10.68.0.134"},
+		{InterfaceIP: "10.68.0.135"},
+		// Outside the subnet or reserved.
+		{InterfaceIP: "10.68.0.1"},
+		{InterfaceIP: "10.68.0.191"},
+		{InterfaceIP: "invalid"},
+	}
+	usage := NewSubnetUsage(subnet, interfaces)
+	assert.Equal(t, &SubnetUsage{
+		SubnetID: 1185,
+		Prefix:   subnet.Prefix,
+		Total:    64,
+		Used:     3,
+		Reserved: 2,
+		Free:     59,
+		FreeRanges: []AddressRange{
+			addrRange("10.68.0.130", "10.68.0.133"),
+			addrRange("10.68.0.136", "10.68.0.190"),
+		},
+	}, usage)
+	assert.InDelta(t, 3.0/62, usage.Utilization(), 1e-9)
+}
+
+func TestNewSubnetUsageEdges(t *testing.T) {
+	usage := NewSubnetUsage(&Subnet{Prefix: netip.MustParsePrefix("10.0.0.0/31")}, []*InterfaceInfo{{InterfaceIP: "10.0.0.1"}})
+	assert.Equal(t, uint64(2), usage.Total)
+	assert.Equal(t, uint64(0), usage.Reserved)
+	assert.Equal(t, []AddressRange{addrRange("10.0.0.0", "10.0.0.0")}, usage.FreeRanges)
+
+	usage = NewSubnetUsage(&Subnet{Prefix: netip.MustParsePrefix("10.0.0.0/30")}, []*InterfaceInfo{{InterfaceIP: "10.0.0.1"}, {InterfaceIP: "10.0.0.2"}})
+	assert.Equal(t, uint64(0), usage.Free)
+	assert.Empty(t, usage.FreeRanges)
+	assert.Equal(t, 1.0, usage.Utilization())
+
+	usage = NewSubnetUsage(&Subnet{Prefix: netip.MustParsePrefix("2a01:4d0:1::/64")}, []*InterfaceInfo{{InterfaceIP: "2a01:4d0:1::1"}})
+	assert.Equal(t, uint64(math.MaxUint64), usage.Total)
+	assert.Equal(t, uint64(math.MaxUint64), usage.Free)
+	assert.Equal(t, uint64(1), usage.Reserved)
+	assert.Equal(t, []AddressRange{addrRange("2a01:4d0:1::2", "2a01:4d0:1::ffff:ffff:ffff:ffff")}, usage.FreeRanges)
+
+	usage = NewSubnetUsage(&Subnet{Prefix: netip.MustParsePrefix("2a01:4d0:1::/120")}, nil)
+	assert.Equal(t, uint64(256), usage.Total)
+	assert.Equal(t, uint64(255), usage.Free)
+	assert.Equal(t, []AddressRange{addrRange("2a01:4d0:1::1", "2a01:4d0:1::ff")}, usage.FreeRanges)
+}
+
+func TestSubnetUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/=/dhcp_subnet/1185":
+			_, _ = rw.Write([]byte(`{"id": 1185, "subnet": "10.68.0.128/26"}`))
+		case "/=/dhcp_interface/":
+			assert.Equal(t, "1185", req.URL.Query().Get("subnet_id"))
+			_, _ = rw.Write([]byte(`[{"id": 1, "name": "node1", "destination": "10.68.0.129"}]`))
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	usage, err := c.SubnetUsage(context.Background(), 1185)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), usage.Used)
+	assert.Equal(t, uint64(61), usage.Free)
+}
+
+func TestListSubnetUsage(t *testing.T) {
+	const n = 20
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/=/dhcp_subnet":
+			subnets := make([]string, 0, n)
+			for i := range n {
+				subnets = append(subnets, fmt.Sprintf(`{"id": %d, "subnet": "10.68.%d.0/24"}`, i+1, i))
+			}
+			_, _ = rw.Write([]byte("[" + strings.Join(subnets, ",") + "]"))
+		case "/=/dhcp_interface/":
+			cur := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				prev := maxInFlight.Load()
+				if cur <= prev || maxInFlight.CompareAndSwap(prev, cur) {
+					break
+				}
+			}
+			id, _ := strconv.Atoi(req.URL.Query().Get("subnet_id"))
+			_, _ = fmt.Fprintf(rw, `[{"id": %d, "destination": "10.68.%d.10"}]`, id, id-1)
+		}
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	usages, err := c.ListSubnetUsage(context.Background(), SubnetFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, usages, n)
+	for i, u := range usages {
+		assert.Equal(t, i+1, u.SubnetID)
+		assert.Equal(t, uint64(1), u.Used, u.Prefix)
+	}
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(subnetUsageWorkers))
+}
+
+func TestListSubnetUsageError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/=/dhcp_subnet":
+			_, _ = rw.Write([]byte(subnetListResponse))
+		default:
+			rw.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	_, err := c.ListSubnetUsage(context.Background(), SubnetFilter{})
+	assert.ErrorIs(t, err, ErrForbidden)
+}
@@ -0,0 +1,538 @@
+package tidydns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ZoneType int
+
+//goland:noinspection GoUnusedConst
+const (
+	ZoneTypeRegular ZoneType = 0
+	ZoneTypeAlias   ZoneType = 2
+)
+
+// Zone is the full configuration and state of a zone.
+type Zone struct {
+	ID          int
+	Name        string
+	Description string
+	Type        ZoneType
+	// TypeText is the name of the zone type as reported by TidyDNS.
+	TypeText   string
+	Status     int
+	CustomerID int
+	ParentID   int
+	// Serial is the SOA serial of the zone. TidyDNS increments it whenever
+	// the zone is changed.
+	Serial  int
+	Private bool
+	SOA     SOA
+	DNSSEC  DNSSEC
+	// Masters are the primary name servers of a secondary zone.
+	Masters []string
+	// Forwarders are the name servers queries for a forward zone are sent
+	// to.
+	Forwarders []string
+	// AllowTransfer lists the hosts allowed to transfer the zone.
+	AllowTransfer []string
+	// AliasID and AliasName identify the zone an alias zone mirrors.
+	AliasID   int
+	AliasName string
+	// AliasType is the kind of alias as reported by TidyDNS.
+	AliasType int
+	// Network, RangeStart and RangeStop are the addresses covered by a
+	// reverse zone.
+	Network    string
+	RangeStart string
+	RangeStop  string
+	// ReverseClass is the kind of reverse zone as reported by TidyDNS.
+	ReverseClass string
+	// InjectNS adds the NS records of the SOA configuration to the zone.
+	InjectNS bool
+	// AutofillEnabled fills new zones with the records of the template
+	// with ID AutofillTemplate.
+	AutofillEnabled  bool
+	AutofillTemplate int
+	// ProvisionState is the provisioning state as reported by TidyDNS. Use
+	// Provisioned to tell whether the zone has been provisioned.
+	ProvisionState int
+	// ProvisionDate is the time the zone was last provisioned to the name
+	// servers.
+	ProvisionDate time.Time
+	ProvisionLog  string
+	// ServerState is the state of the zone on the name servers as reported
+	// by TidyDNS.
+	ServerState        int
+	AuthoritativeState int
+	// AuthoritativeLog is the result of the latest check of the delegation
+	// of the zone.
+	AuthoritativeLog string
+	LastCheckDate    time.Time
+	CreatedDate      time.Time
+	ModifiedDate     time.Time
+	ModifiedBy       string
+}
+
+// Provisioned reports whether the latest change of the zone has been
+// provisioned to the name servers, i.e. whether the zone was provisioned
+// at or after it was last modified.
+func (z *Zone) Provisioned() bool {
+	return !z.ProvisionDate.IsZero() && !z.ProvisionDate.Before(z.ModifiedDate)
+}
+
+// SOA holds the SOA settings of a zone. Zero values mean the defaults of
+// the TidyDNS installation are used.
+type SOA struct {
+	TTL     time.Duration
+	Refresh time.Duration
+	Retry   time.Duration
+	Expire  time.Duration
+	// MinimumTTL is the time negative answers may be cached.
+	MinimumTTL time.Duration
+	Contact    string
+}
+
+// DNSSEC holds the DNSSEC settings and signing state of a zone.
+type DNSSEC struct {
+	Enabled bool
+	// GenerateKeys makes TidyDNS generate new keys when the zone is next
+	// provisioned.
+	GenerateKeys bool
+	// Monitor enables monitoring of the DS records of the parent zone.
+	Monitor    bool
+	LastSigned time.Time
+	// ParentState is the state of the DS records in the parent zone as
+	// reported by TidyDNS, and ParentLog the log of the latest check.
+	ParentState int
+	ParentLog   string
+}
+
+// ZoneSettings are the settings of a zone that can be changed through the
+// API. Nil fields are left unchanged by UpdateZone and get the defaults of
+// the TidyDNS installation on CreateZone. An empty, non-nil list clears the
+// list.
+type ZoneSettings struct {
+	Description *string
+	// SOA timers are set in whole seconds.
+	SOATTL        *time.Duration
+	SOARefresh    *time.Duration
+	SOARetry      *time.Duration
+	SOAExpire     *time.Duration
+	SOAMinimumTTL *time.Duration
+	SOAContact    *string
+	// Masters and Forwarders are IP addresses of name servers.
+	Masters    []string
+	Forwarders []string
+	// AllowTransfer are IP addresses or networks allowed to transfer the
+	// zone.
+	AllowTransfer      []string
+	DNSSECEnabled      *bool
+	DNSSECGenerateKeys *bool
+	DNSSECMonitor      *bool
+	InjectNS           *bool
+	Private            *bool
+	AutofillEnabled    *bool
+	AutofillTemplate   *int
+}
+
+// Validate returns an error matching ErrInvalidArgument if the settings
+// cannot be stored in TidyDNS.
+func (s ZoneSettings) Validate() error {
+	timers := map[string]*time.Duration{
+		"SOA TTL":         s.SOATTL,
+		"SOA refresh":     s.SOARefresh,
+		"SOA retry":       s.SOARetry,
+		"SOA expire":      s.SOAExpire,
+		"SOA minimum TTL": s.SOAMinimumTTL,
+	}
+	for name, d := range timers {
+		if d == nil {
+			continue
+		}
+		if *d < 0 || *d%time.Second != 0 || d.Seconds() > math.MaxInt32 {
+			return fmt.Errorf("%w: %s must be a non-negative whole number of seconds, not %s", ErrInvalidArgument, name, d)
+		}
+	}
+	if s.SOAContact != nil && *s.SOAContact != "" {
+		// The contact is a mailbox, written either as an e-mail address or
+		// in the domain name form of the SOA record.
+		if err := validateHostname("SOA contact", strings.Replace(*s.SOAContact, "@", ".", 1)); err != nil {
+			return err
+		}
+	}
+	servers := map[string][]string{"master": s.Masters, "forwarder": s.Forwarders}
+	for name, list := range servers {
+		for _, addr := range list {
+			if _, err := netip.ParseAddr(addr); err != nil {
+				return fmt.Errorf("%w: %s %q is not an IP address", ErrInvalidArgument, name, addr)
+			}
+		}
+	}
+	for _, addr := range s.AllowTransfer {
+		if _, err := netip.ParseAddr(addr); err == nil {
+			continue
+		}
+		if _, err := netip.ParsePrefix(addr); err != nil {
+			return fmt.Errorf("%w: allow transfer %q is not an IP address or network", ErrInvalidArgument, addr)
+		}
+	}
+	if s.AutofillTemplate != nil && *s.AutofillTemplate < 0 {
+		return fmt.Errorf("%w: invalid autofill template %d", ErrInvalidArgument, *s.AutofillTemplate)
+	}
+	return nil
+}
+
+// form adds the settings to a create or update request.
+func (s ZoneSettings) form(data url.Values) {
+	if s.Description != nil {
+		data.Set("description", *s.Description)
+	}
+	timers := map[string]*time.Duration{
+		"soa_ttl":              s.SOATTL,
+		"soa_slave_refresh":    s.SOARefresh,
+		"soa_slave_retry":      s.SOARetry,
+		"soa_slave_expiration": s.SOAExpire,
+		"soa_max_caching":      s.SOAMinimumTTL,
+	}
+	for key, d := range timers {
+		if d != nil {
+			data.Set(key, strconv.Itoa(int(d.Seconds())))
+		}
+	}
+	if s.SOAContact != nil {
+		data.Set("soa_contact", *s.SOAContact)
+	}
+	lists := map[string][]string{
+		"masters":        s.Masters,
+		"forwarders":     s.Forwarders,
+		"allow_transfer": s.AllowTransfer,
+	}
+	for key, list := range lists {
+		if list != nil {
+			data.Set(key, strings.Join(list, ";"))
+		}
+	}
+	flags := map[string]*bool{
+		"dnssec_enable":         s.DNSSECEnabled,
+		"dnssec_genkeys":        s.DNSSECGenerateKeys,
+		"dnssec_monitor_enable": s.DNSSECMonitor,
+		"inject_ns_enable":      s.InjectNS,
+		"is_private":            s.Private,
+		"autofill_enable":       s.AutofillEnabled,
+	}
+	for key, b := range flags {
+		if b != nil {
+			data.Set(key, formBool(*b))
+		}
+	}
+	if s.AutofillTemplate != nil {
+		data.Set("autofill_template", strconv.Itoa(*s.AutofillTemplate))
+	}
+}
+
+func formBool(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+type zoneCreate struct {
+	ID   int `json:"id"`
+	Data struct {
+		ID int `json:"id"`
+	} `json:"data"`
+}
+
+type zoneRead struct {
+	ID                  int         `json:"id"`
+	Name                string      `json:"name"`
+	Description         string      `json:"description"`
+	Type                ZoneType    `json:"type"`
+	TypeText            string      `json:"type_text"`
+	Status              int         `json:"status"`
+	CustomerID          int         `json:"customer_id"`
+	ParentID            int         `json:"parent_id"`
+	Serial              int         `json:"serial"`
+	IsPrivate           int         `json:"is_private"`
+	SOATTL              int         `json:"soa_ttl"`
+	SOARefresh          int         `json:"soa_slave_refresh"`
+	SOARetry            int         `json:"soa_slave_retry"`
+	SOAExpire           int         `json:"soa_slave_expiration"`
+	SOAMaxCaching       int         `json:"soa_max_caching"`
+	SOAContact          string      `json:"soa_contact"`
+	DNSSECEnable        int         `json:"dnssec_enable"`
+	DNSSECGenKeys       int         `json:"dnssec_genkeys"`
+	DNSSECMonitorEnable int         `json:"dnssec_monitor_enable"`
+	DNSSECLastSign      string      `json:"dnssec_lastsign"`
+	DNSSECParentState   int         `json:"dnssec_parent_state"`
+	DNSSECParentLog     string      `json:"dnssec_parent_log"`
+	Masters             hostList    `json:"masters"`
+	Forwarders          hostList    `json:"forwarders"`
+	AllowTransfer       hostList    `json:"allow_transfer"`
+	AliasID             int         `json:"alias_id"`
+	AliasName           string      `json:"alias_name"`
+	AliasType           nullableInt `json:"alias_type"`
+	Network             string      `json:"network"`
+	RangeStart          string      `json:"range_start"`
+	RangeStop           string      `json:"range_stop"`
+	ReverseNetwork      string      `json:"reverse_network"`
+	ReverseRangeStart   string      `json:"reverse_range_start"`
+	ReverseRangeStop    string      `json:"reverse_range_stop"`
+	ReverseClass        string      `json:"reverse_class"`
+	InjectNSEnable      int         `json:"inject_ns_enable"`
+	AutofillEnable      int         `json:"autofill_enable"`
+	AutofillTemplate    nullableInt `json:"autofill_template"`
+	ProvisionState      int         `json:"provision_state"`
+	ProvisionDate       string      `json:"provision_date"`
+	ProvisionLog        string      `json:"provision_log"`
+	ServerState         nullableInt `json:"server_state"`
+	AuthoritativeState  int         `json:"authoritative_state"`
+	AuthoritativeLog    string      `json:"authoritative_log"`
+	LastCheckDate       string      `json:"last_check_date"`
+	CreatedDate         string      `json:"created_date"`
+	ModifiedDate        string      `json:"modified_date"`
+	ModifiedBy          string      `json:"modified_by"`
+}
+
+func (z zoneRead) zone() (*Zone, error) {
+	zone := &Zone{
+		ID:          z.ID,
+		Name:        z.Name,
+		Description: z.Description,
+		Type:        z.Type,
+		TypeText:    z.TypeText,
+		Status:      z.Status,
+		CustomerID:  z.CustomerID,
+		ParentID:    z.ParentID,
+		Serial:      z.Serial,
+		Private:     z.IsPrivate != 0,
+		SOA: SOA{
+			TTL:        seconds(z.SOATTL),
+			Refresh:    seconds(z.SOARefresh),
+			Retry:      seconds(z.SOARetry),
+			Expire:     seconds(z.SOAExpire),
+			MinimumTTL: seconds(z.SOAMaxCaching),
+			Contact:    z.SOAContact,
+		},
+		DNSSEC: DNSSEC{
+			Enabled:      z.DNSSECEnable != 0,
+			GenerateKeys: z.DNSSECGenKeys != 0,
+			Monitor:      z.DNSSECMonitorEnable != 0,
+			ParentState:  z.DNSSECParentState,
+			ParentLog:    z.DNSSECParentLog,
+		},
+		Masters:            z.Masters,
+		Forwarders:         z.Forwarders,
+		AllowTransfer:      z.AllowTransfer,
+		AliasID:            z.AliasID,
+		AliasName:          z.AliasName,
+		AliasType:          z.AliasType.Int,
+		Network:            firstNonEmpty(z.ReverseNetwork, z.Network),
+		RangeStart:         firstNonEmpty(z.ReverseRangeStart, z.RangeStart),
+		RangeStop:          firstNonEmpty(z.ReverseRangeStop, z.RangeStop),
+		ReverseClass:       z.ReverseClass,
+		InjectNS:           z.InjectNSEnable != 0,
+		AutofillEnabled:    z.AutofillEnable != 0,
+		AutofillTemplate:   z.AutofillTemplate.Int,
+		ProvisionState:     z.ProvisionState,
+		ProvisionLog:       z.ProvisionLog,
+		ServerState:        z.ServerState.Int,
+		AuthoritativeState: z.AuthoritativeState,
+		AuthoritativeLog:   z.AuthoritativeLog,
+		ModifiedBy:         z.ModifiedBy,
+	}
+
+	dates := []struct {
+		value string
+		dst   *time.Time
+	}{
+		{z.DNSSECLastSign, &zone.DNSSEC.LastSigned},
+		{z.ProvisionDate, &zone.ProvisionDate},
+		{z.LastCheckDate, &zone.LastCheckDate},
+		{z.CreatedDate, &zone.CreatedDate},
+		{z.ModifiedDate, &zone.ModifiedDate},
+	}
+	for _, d := range dates {
+		if d.value == "" {
+			continue
+		}
+		t, err := time.Parse(time.DateTime, d.value)
+		if err != nil {
+			return nil, fmt.Errorf("zone %d: %w", z.ID, err)
+		}
+		*d.dst = t
+	}
+	return zone, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
+}
+
+// hostList is a list of hosts or networks, which TidyDNS reports either as
+// an array or as a single string separated by commas, semicolons or spaces.
+type hostList []string
+
+func (l *hostList) UnmarshalJSON(data []byte) error {
+	var hosts []string
+	if err := json.Unmarshal(data, &hosts); err == nil {
+		*l = hosts
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid host list: %s", data)
+	}
+	*l = strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '\t' || r == '\n'
+	})
+	if len(*l) == 0 {
+		*l = nil
+	}
+	return nil
+}
+
+func (c *tidyDNSClient) GetZone(ctx context.Context, zoneID int) (_ *Zone, err error) {
+	ctx, end := c.startOperation(ctx, "GetZone", attrZoneID.Int(zoneID))
+	defer func() { end(err) }()
+
+	var zone zoneRead
+	zoneUrl := fmt.Sprintf("%s/=/zone/%d?type=json", c.baseURL, zoneID)
+	err = c.getData(
+		ctx,
+		zoneUrl,
+		&zone,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return zone.zone()
+}
+
+func (c *tidyDNSClient) ListZonesDetailed(ctx context.Context) (_ []*Zone, err error) {
+	ctx, end := c.startOperation(ctx, "ListZonesDetailed")
+	defer func() { end(err) }()
+
+	var zones []zoneRead
+	zoneListUrl := fmt.Sprintf("%s/=/zone?type=json", c.baseURL)
+	err = c.getData(
+		ctx,
+		zoneListUrl,
+		&zones,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Zone, 0, len(zones))
+	for _, z := range zones {
+		zone, err := z.zone()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, zone)
+	}
+	return result, nil
+}
+
+func (c *tidyDNSClient) CreateZone(ctx context.Context, name string, settings ZoneSettings) (_ int, err error) {
+	ctx, end := c.startOperation(ctx, "CreateZone", attrZoneName.String(name))
+	defer func() { end(err) }()
+
+	if err := validateHostname("zone name", name); err != nil {
+		return 0, err
+	}
+	if err := settings.Validate(); err != nil {
+		return 0, err
+	}
+
+	data := url.Values{
+		"name": {strings.TrimSuffix(name, ".")},
+	}
+	settings.form(data)
+	return c.createZone(ctx, data)
+}
+
+// createZone posts a new zone and returns its ID.
+func (c *tidyDNSClient) createZone(ctx context.Context, data url.Values) (int, error) {
+	var zone zoneCreate
+	newZoneUrl := fmt.Sprintf("%s/=/zone/new", c.baseURL)
+	err := c.postForm(ctx, newZoneUrl, data, &zone)
+	if err != nil {
+		return 0, err
+	}
+
+	if zone.ID != 0 {
+		return zone.ID, nil
+	}
+	if zone.Data.ID != 0 {
+		return zone.Data.ID, nil
+	}
+	// Like records, some versions of TidyDNS do not return the ID of new
+	// zones.
+	z, err := c.findZone(ctx, data.Get("name"))
+	if err != nil {
+		return 0, err
+	}
+	return z.ID, nil
+}
+
+func (c *tidyDNSClient) UpdateZone(ctx context.Context, zoneID int, settings ZoneSettings) (err error) {
+	ctx, end := c.startOperation(ctx, "UpdateZone", attrZoneID.Int(zoneID))
+	defer func() { end(err) }()
+
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	data := url.Values{}
+	settings.form(data)
+
+	zoneUrl := fmt.Sprintf("%s/=/zone/%d", c.baseURL, zoneID)
+	return c.postForm(ctx, zoneUrl, data, nil)
+}
+
+func (c *tidyDNSClient) DeleteZone(ctx context.Context, zoneID int) (err error) {
+	ctx, end := c.startOperation(ctx, "DeleteZone", attrZoneID.Int(zoneID))
+	defer func() { end(err) }()
+
+	zoneUrl := fmt.Sprintf("%s/=/zone/%d", c.baseURL, zoneID)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"DELETE",
+		zoneUrl,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer closeResponse(res)
+
+	return nil
+}
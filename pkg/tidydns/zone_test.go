@@ -0,0 +1,263 @@
+package tidydns
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetZone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/=/zone/279", req.URL.Path)
+		assert.Equal(t, "GET", req.Method)
+		_, _ = rw.Write([]byte(getZoneResponse))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	zone, err := c.GetZone(context.Background(), 279)
+	assert.NoError(t, err)
+	assert.Equal(t, 279, zone.ID)
+	assert.Equal(t, "netic.dk", zone.Name)
+	assert.Equal(t, ZoneTypeRegular, zone.Type)
+	assert.Equal(t, 17830, zone.Serial)
+	assert.Equal(t, SOA{
+		TTL:        time.Hour,
+		Refresh:    3 * time.Hour,
+		Retry:      15 * time.Minute,
+		Expire:     7 * 24 * time.Hour,
+		MinimumTTL: 5 * time.Minute,
+		Contact:    "hostmaster.netic.dk",
+	}, zone.SOA)
+	assert.True(t, zone.DNSSEC.Enabled)
+	assert.True(t, zone.DNSSEC.Monitor)
+	assert.False(t, zone.DNSSEC.GenerateKeys)
+	assert.Equal(t, 1, zone.DNSSEC.ParentState)
+	assert.Equal(t, time.Date(2021, 10, 4, 13, 38, 56, 0, time.UTC), zone.DNSSEC.LastSigned)
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, zone.AllowTransfer)
+	assert.Nil(t, zone.Masters)
+	assert.Equal(t, 2, zone.ProvisionState)
+	assert.True(t, zone.Provisioned())
+	assert.Equal(t, time.Date(2021, 10, 4, 13, 38, 56, 0, time.UTC), zone.ProvisionDate)
+	assert.True(t, zone.Private)
+	assert.True(t, zone.InjectNS)
+	assert.Equal(t, 1, zone.AuthoritativeState)
+	assert.Equal(t, "mni", zone.ModifiedBy)
+}
+
+func TestGetZoneNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	_, err := c.GetZone(context.Background(), 1)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestListZonesDetailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "GET", req.Method)
+		_, _ = rw.Write([]byte(listZonesResponse))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	zones, err := c.ListZonesDetailed(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, zones, 4)
+
+	assert.Equal(t, "hackerdays.trifork.dev", zones[0].Name)
+	assert.Equal(t, 3, zones[0].AuthoritativeState)
+	assert.Equal(t, -1, zones[0].DNSSEC.ParentState)
+	assert.True(t, zones[0].DNSSEC.LastSigned.IsZero())
+	assert.Equal(t, 279, zones[1].ParentID)
+
+	alias := zones[3]
+	assert.Equal(t, "netic.eu", alias.Name)
+	assert.Equal(t, ZoneTypeAlias, alias.Type)
+	assert.Equal(t, "alias", alias.TypeText)
+	assert.Equal(t, 279, alias.AliasID)
+	assert.Equal(t, "netic.dk", alias.AliasName)
+	assert.Equal(t, time.Date(2009, 4, 14, 10, 10, 50, 0, time.UTC), alias.CreatedDate)
+}
+
+func TestHostList(t *testing.T) {
+	tests := map[string][]string{
+		`null`:                        nil,
+		`""`:                          nil,
+		`"10.0.0.1"`:                  {"10.0.0.1"},
+		`"10.0.0.1; 10.0.0.2,::1"`:    {"10.0.0.1", "10.0.0.2", "::1"},
+		`["10.0.0.1", "10.0.0.0/24"]`: {"10.0.0.1", "10.0.0.0/24"},
+	}
+	for in, want := range tests {
+		var l hostList
+		assert.NoError(t, json.Unmarshal([]byte(in), &l), in)
+		assert.Equal(t, want, []string(l), in)
+	}
+
+	var l hostList
+	assert.Error(t, json.Unmarshal([]byte(`42`), &l))
+}
+
+const getZoneResponse = `{
+  "id": 279,
+  "name": "netic.dk",
+  "zone_name": "netic.dk",
+  "description": "Netic A/S, Aalborg, Denmark",
+  "type": 0,
+  "type_text": "regular",
+  "status": 0,
+  "customer_id": 0,
+  "parent_id": null,
+  "serial": 17830,
+  "is_private": 1,
+  "soa_ttl": 3600,
+  "soa_slave_refresh": 10800,
+  "soa_slave_retry": 900,
+  "soa_slave_expiration": 604800,
+  "soa_max_caching": 300,
+  "soa_contact": "hostmaster.netic.dk",
+  "soa_record": null,
+  "dnssec_enable": 1,
+  "dnssec_genkeys": 0,
+  "dnssec_monitor_enable": 1,
+  "dnssec_lastsign": "2021-10-04 13:38:56",
+  "dnssec_parent_state": 1,
+  "dnssec_parent_log": "",
+  "masters": null,
+  "forwarders": null,
+  "allow_transfer": "10.0.0.1;10.0.0.2",
+  "alias_id": null,
+  "alias_name": null,
+  "inject_ns_enable": 1,
+  "provision_state": 2,
+  "provision_date": "2021-10-04 13:38:56",
+  "provision_log": "",
+  "authoritative_state": 1,
+  "authoritative_log": "NOERROR: ASKED(a.ns.netic.dk b.ns.netic.dk c.ns.netic.dk): NS(a.ns.netic.dk b.ns.netic.dk c.ns.netic.dk)",
+  "last_check_date": "2021-10-04 07:58:18",
+  "created_date": "2004-09-20 15:24:56",
+  "modified_date": "2021-10-04 13:38:36",
+  "modified_by": "mni"
+}`
+
+func TestCreateZone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "POST", req.Method)
+		assert.Equal(t, "/=/zone/new", req.URL.Path)
+		assert.Equal(t, mimeForm, req.Header.Get(headerContentType))
+		assert.NoError(t, req.ParseForm())
+		assert.Equal(t, "example.com", req.PostForm.Get("name"))
+		assert.Equal(t, "Customer", req.PostForm.Get("description"))
+		assert.Equal(t, "3600", req.PostForm.Get("soa_ttl"))
+		assert.Equal(t, "300", req.PostForm.Get("soa_max_caching"))
+		assert.Equal(t, "hostmaster@example.com", req.PostForm.Get("soa_contact"))
+		assert.Equal(t, "10.0.0.1;10.0.1.0/24", req.PostForm.Get("allow_transfer"))
+		assert.Equal(t, "1", req.PostForm.Get("dnssec_enable"))
+		assert.Equal(t, "7", req.PostForm.Get("autofill_template"))
+		assert.False(t, req.PostForm.Has("masters"))
+		assert.False(t, req.PostForm.Has("soa_slave_retry"))
+		_, _ = rw.Write([]byte(`{"status":"0","data":{"id":3001}}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	id, err := c.CreateZone(context.Background(), "example.com.", ZoneSettings{
+		Description:      toPtr("Customer"),
+		SOATTL:           toPtr(time.Hour),
+		SOAMinimumTTL:    toPtr(5 * time.Minute),
+		SOAContact:       toPtr("hostmaster@example.com"),
+		AllowTransfer:    []string{"10.0.0.1", "10.0.1.0/24"},
+		DNSSECEnabled:    toPtr(true),
+		AutofillTemplate: toPtr(7),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3001, id)
+}
+
+func TestCreateZoneLookup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method == "POST" {
+			_, _ = rw.Write([]byte(`{"status":"0"}`))
+			return
+		}
+		assert.Equal(t, "example.com", req.URL.Query().Get("name"))
+		_, _ = rw.Write([]byte(`[{"id":3002,"name":"example.com"}]`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	id, err := c.CreateZone(context.Background(), "example.com", ZoneSettings{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3002, id)
+}
+
+func TestCreateZoneInvalid(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		t.Error("unexpected request")
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	invalid := []ZoneSettings{
+		{SOATTL: toPtr(-time.Second)},
+		{SOARetry: toPtr(1500 * time.Millisecond)},
+		{SOAContact: toPtr("not a contact")},
+		{Masters: []string{"ns1.example.com"}},
+		{Forwarders: []string{"10.0.0.0/8"}},
+		{AllowTransfer: []string{"any"}},
+		{AutofillTemplate: toPtr(-1)},
+	}
+	for _, settings := range invalid {
+		_, err := c.CreateZone(context.Background(), "example.com", settings)
+		assert.ErrorIs(t, err, ErrInvalidArgument, "%+v", settings)
+	}
+
+	_, err := c.CreateZone(context.Background(), "bad..name", ZoneSettings{})
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+	err = c.UpdateZone(context.Background(), 1, ZoneSettings{Masters: []string{"x"}})
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+}
+
+func TestUpdateZone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "POST", req.Method)
+		assert.Equal(t, "/=/zone/279", req.URL.Path)
+		assert.NoError(t, req.ParseForm())
+		assert.Equal(t, url.Values{
+			"soa_slave_refresh": {"10800"},
+			"forwarders":        {""},
+			"dnssec_genkeys":    {"0"},
+		}, req.PostForm)
+		_, _ = rw.Write([]byte(`{"status":"0","data":{"id":279}}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	err := c.UpdateZone(context.Background(), 279, ZoneSettings{
+		SOARefresh:         toPtr(3 * time.Hour),
+		Forwarders:         []string{},
+		DNSSECGenerateKeys: toPtr(false),
+	})
+	assert.NoError(t, err)
+}
+
+func TestDeleteZone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "DELETE", req.Method)
+		assert.Equal(t, "/=/zone/279", req.URL.Path)
+		_, _ = rw.Write([]byte(`{"status":"0"}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	assert.NoError(t, c.DeleteZone(context.Background(), 279))
+}
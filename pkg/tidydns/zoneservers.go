@@ -0,0 +1,72 @@
+package tidydns
+
+import (
+	"context"
+	"slices"
+)
+
+// ZoneServerConfig is the name servers a zone is transferred from or
+// forwarded to, and the hosts allowed to transfer it. The kind of zone is
+// reported in Zone.TypeText, but cannot be changed through this package.
+type ZoneServerConfig struct {
+	// Masters are the IP addresses of the primary name servers of a
+	// secondary zone.
+	Masters []string
+	// Forwarders are the IP addresses of the name servers queries for a
+	// forward zone are sent to.
+	Forwarders []string
+	// AllowTransfer are IP addresses or networks allowed to transfer the
+	// zone from TidyDNS.
+	AllowTransfer []string
+}
+
+// Validate returns an error matching ErrInvalidArgument if the
+// configuration holds anything but IP addresses, or networks for
+// AllowTransfer.
+func (c ZoneServerConfig) Validate() error {
+	return c.Settings().Validate()
+}
+
+// Settings returns the zone settings replacing the masters, forwarders and
+// allowed transfers of a zone with the configuration.
+func (c ZoneServerConfig) Settings() ZoneSettings {
+	// Empty, non-nil lists clear the lists in TidyDNS.
+	list := func(l []string) []string {
+		return append([]string{}, l...)
+	}
+	return ZoneSettings{
+		Masters:       list(c.Masters),
+		Forwarders:    list(c.Forwarders),
+		AllowTransfer: list(c.AllowTransfer),
+	}
+}
+
+// NewZoneServerConfig returns the server configuration of a zone.
+func NewZoneServerConfig(zone *Zone) *ZoneServerConfig {
+	return &ZoneServerConfig{
+		Masters:       slices.Clone(zone.Masters),
+		Forwarders:    slices.Clone(zone.Forwarders),
+		AllowTransfer: slices.Clone(zone.AllowTransfer),
+	}
+}
+
+func (c *tidyDNSClient) GetZoneServerConfig(ctx context.Context, zoneID int) (_ *ZoneServerConfig, err error) {
+	ctx, end := c.startOperation(ctx, "GetZoneServerConfig", attrZoneID.Int(zoneID))
+	defer func() { end(err) }()
+
+	zone, err := c.GetZone(ctx, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	return NewZoneServerConfig(zone), nil
+}
+
+func (c *tidyDNSClient) UpdateZoneServerConfig(ctx context.Context, zoneID int, config ZoneServerConfig) (err error) {
+	ctx, end := c.startOperation(ctx, "UpdateZoneServerConfig", attrZoneID.Int(zoneID))
+	defer func() { end(err) }()
+
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	return c.UpdateZone(ctx, zoneID, config.Settings())
+}
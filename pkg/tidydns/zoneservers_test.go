@@ -0,0 +1,71 @@
+package tidydns
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZoneServerConfigValidate(t *testing.T) {
+	valid := []ZoneServerConfig{
+		{},
+		{AllowTransfer: []string{"10.0.0.0/24"}},
+		{Masters: []string{"10.0.0.1", "2001:db8::1"}},
+		{Forwarders: []string{"10.0.0.1"}},
+	}
+	for _, config := range valid {
+		assert.NoError(t, config.Validate(), "%+v", config)
+	}
+
+	invalid := []ZoneServerConfig{
+		{Masters: []string{"ns1.example.com"}},
+		{Forwarders: []string{"10.0.0.0/24"}},
+		{Forwarders: []string{"10.0.0.1"}, AllowTransfer: []string{"any"}},
+	}
+	for _, config := range invalid {
+		assert.ErrorIs(t, config.Validate(), ErrInvalidArgument, "%+v", config)
+	}
+}
+
+func TestGetZoneServerConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/=/zone/3001", req.URL.Path)
+		_, _ = rw.Write([]byte(`{"id": 3001, "name": "customer.dk", "type": 0, "masters": "192.0.2.1; 192.0.2.2", "allow_transfer": null}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	config, err := c.GetZoneServerConfig(context.Background(), 3001)
+	assert.NoError(t, err)
+	assert.Equal(t, &ZoneServerConfig{
+		Masters: []string{"192.0.2.1", "192.0.2.2"},
+	}, config)
+}
+
+func TestUpdateZoneServerConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "POST", req.Method)
+		assert.Equal(t, "/=/zone/3001", req.URL.Path)
+		assert.NoError(t, req.ParseForm())
+		assert.Equal(t, url.Values{
+			"masters":        {""},
+			"forwarders":     {"192.0.2.1;192.0.2.2"},
+			"allow_transfer": {""},
+		}, req.PostForm)
+		_, _ = rw.Write([]byte(`{"status":"0"}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "username", "password")
+	err := c.UpdateZoneServerConfig(context.Background(), 3001, ZoneServerConfig{
+		Forwarders: []string{"192.0.2.1", "192.0.2.2"},
+	})
+	assert.NoError(t, err)
+
+	err = c.UpdateZoneServerConfig(context.Background(), 3001, ZoneServerConfig{Masters: []string{"ns1.example.com"}})
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+}